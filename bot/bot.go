@@ -6,7 +6,15 @@ import (
 	"fmt"
 	"github.com/nlopes/slack"
 	"log"
+	"os"
+	"os/signal"
 	"regexp"
+	"syscall"
+)
+
+const (
+	messageChangedSubType = "message_changed"
+	messageDeletedSubType = "message_deleted"
 )
 
 var botMentionRegex *regexp.Regexp = nil
@@ -22,17 +30,27 @@ func Run(token string) {
 		log.Println("Creating mods failed.")
 		return
 	}
+	defer modContainer.CloseMods()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
 	go rtm.ManageConnection()
 	for {
 		select {
+		case <-signals:
+			log.Println("Shutting down.")
+			return
 		case response := <-modContainer.AsyncResponse:
 			respond(rtm, response.Message, response.ChannelId)
 		case msg := <-rtm.IncomingEvents:
 			switch event := msg.Data.(type) {
 			case *slack.ConnectedEvent:
-				onConnect(event.Info)
+				onConnect(event.Info, modContainer)
 			case *slack.MessageEvent:
 				onMessage(rtm, event, modContainer)
+			case *slack.UserChangeEvent:
+				modContainer.UpdateUser(event.User)
+			case *slack.TeamJoinEvent:
+				modContainer.UpdateUser(event.User)
 			case *slack.RTMError:
 				onError(event)
 			case *slack.InvalidAuthEvent:
@@ -58,8 +76,12 @@ func createModContainer(slackClient *slack.Client) *mods.ModContainer {
 	return container
 }
 
-func onConnect(info *slack.Info) {
+func onConnect(info *slack.Info, modContainer *mods.ModContainer) {
 	logInfo(info)
+	// Users are left out of connection info of large teams.
+	if len(info.Users) > 0 {
+		modContainer.UpdateUsers(info.Users)
+	}
 	compileMentionRegex(info.User.ID)
 	connected = true
 }
@@ -71,14 +93,13 @@ func onMessage(
 
 	if connected {
 		var response *mods.Response = nil
-		message := event.Text
-		userId := event.User
-		channelId := event.Channel
-		if isMention(message) {
-			message = removeMentions(message)
-			response = modContainer.ProcessMention(message, userId, channelId)
-		} else {
-			response = modContainer.ProcessMessage(message, userId, channelId)
+		switch event.SubType {
+		case messageChangedSubType:
+			response = onMessageChanged(event, modContainer)
+		case messageDeletedSubType:
+			response = modContainer.ProcessMessageDelete(event.Channel, event.DeletedTimestamp)
+		default:
+			response = onNewMessage(event, modContainer)
 		}
 		if response != nil {
 			respond(rtm, response.Message, response.ChannelId)
@@ -86,6 +107,31 @@ func onMessage(
 	}
 }
 
+func onNewMessage(event *slack.MessageEvent, modContainer *mods.ModContainer) *mods.Response {
+	message := event.Text
+	userId := event.User
+	channelId := event.Channel
+	if isMention(message) {
+		message = removeMentions(message)
+		return modContainer.ProcessMention(message, userId, channelId)
+	}
+	return modContainer.ProcessMessage(message, userId, channelId, event.Timestamp)
+}
+
+// Edited message is sent as a submessage keeping timestamp of the original
+// message. Edited mentions are not answered again. Changes which keep the
+// text, like attachments unfurled by Slack, are skipped.
+func onMessageChanged(event *slack.MessageEvent, modContainer *mods.ModContainer) *mods.Response {
+	edited := event.SubMessage
+	if edited == nil || isMention(edited.Text) {
+		return nil
+	}
+	if event.PreviousMessage != nil && event.PreviousMessage.Text == edited.Text {
+		return nil
+	}
+	return modContainer.ProcessMessageEdit(edited.Text, edited.User, event.Channel, edited.Timestamp)
+}
+
 func onError(err *slack.RTMError) {
 	log.Printf("RTM Error: %s\n", err.Error())
 }
@@ -1,6 +1,7 @@
 package bot
 
 import (
+	"github.com/nlopes/slack"
 	"testing"
 )
 
@@ -27,6 +28,17 @@ func TestMentions(t *testing.T) {
 	assertIsNotMention(t, "<@bot>")
 }
 
+func TestMessageChangedWithSameText(t *testing.T) {
+	compileMentionRegex("bot")
+	event := &slack.MessageEvent{
+		SubMessage:      &slack.Msg{Text: "some text", Timestamp: "1.1"},
+		PreviousMessage: &slack.Msg{Text: "some text", Timestamp: "1.1"},
+	}
+	if response := onMessageChanged(event, nil); response != nil {
+		t.Fatalf("Expected message with unchanged text skipped, got %v", response)
+	}
+}
+
 func assertIsMention(t *testing.T, message string) {
 	if !isMention(message) {
 		t.Fatalf("Mention not found in message '%s'", message)
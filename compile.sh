@@ -2,4 +2,5 @@
 ./version.sh > ./bin/version.txt
 mkdir -p ./bin/mods/modswears
 cp -u ./swears.txt ./bin/mods/modswears/swears.txt
+cp -u ./rules.json ./bin/mods/modswears/rules.json
 go build -o ./bin/swbot.exe main.go
\ No newline at end of file
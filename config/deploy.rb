@@ -3,8 +3,14 @@ lock '3.4.0'
 application_files = [
   'bin/log.txt',
   'bin/mods/settings.json',
+  'bin/mods/users.json',
   'bin/mods/modswears/stats.json',
-  'bin/mods/modswears/swears.txt']
+  'bin/mods/modswears/stats.log',
+  'bin/mods/modswears/stats.db',
+  'bin/mods/modswears/swears.txt',
+  'bin/mods/modswears/rules.json',
+  'bin/mods/modswears/jar.json',
+  'bin/mods/modswears/corrections.json']
 
 downloadable_files = [
   'bin/token.txt',
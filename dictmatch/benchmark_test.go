@@ -0,0 +1,132 @@
+package dictmatch
+
+import (
+	"math/rand"
+	"runtime"
+	"testing"
+)
+
+const (
+	benchEntryCount = 20000
+	benchWordCount  = 1000
+	benchAlphabet   = "abcdefghijklmnoprstuwyząćęłńóśźż"
+)
+
+func BenchmarkAddEntries(b *testing.B) {
+	entries := benchEntries()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		benchDict(entries)
+	}
+}
+
+func BenchmarkDictMemory(b *testing.B) {
+	entries := benchEntries()
+	var dict *Dict
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	for i := 0; i < b.N; i++ {
+		dict = benchDict(entries)
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(dict)
+	perDict := float64(after.TotalAlloc-before.TotalAlloc) / float64(b.N)
+	b.ReportMetric(perDict/float64(len(entries)), "B/entry")
+	b.ReportMetric(float64(after.HeapInuse)/float64(len(entries)), "heap-B/entry")
+}
+
+func BenchmarkMatch(b *testing.B) {
+	dict := benchDict(benchEntries())
+	words := benchWords(0)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dict.Match(words[i%len(words)])
+	}
+}
+
+func BenchmarkMatchCollapse(b *testing.B) {
+	dict := NewDictMode(CollapseRepeats | FoldDiacritics)
+	for _, entry := range benchEntries() {
+		dict.AddEntry(entry)
+	}
+	words := benchWords(2)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dict.Match(words[i%len(words)])
+	}
+}
+
+func BenchmarkMatchMiss(b *testing.B) {
+	dict := benchDict(benchEntries())
+	words := make([]string, benchWordCount)
+	random := rand.New(rand.NewSource(2))
+	for i := range words {
+		words[i] = benchWord(random, 12)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dict.Match(words[i%len(words)])
+	}
+}
+
+// Every fourth entry is a prefix wildcard and every sixteenth has an infix
+// wildcard. Entries rejected by the dictionary are skipped when added.
+func benchEntries() []string {
+	random := rand.New(rand.NewSource(1))
+	entries := make([]string, benchEntryCount)
+	for i := range entries {
+		entry := benchWord(random, 4+random.Intn(8))
+		if i%16 == 0 {
+			runes := []rune(entry)
+			entry = string(runes[:2]) + "*" + string(runes[2:])
+		} else if i%4 == 0 {
+			entry += "*"
+		}
+		entries[i] = entry
+	}
+	return entries
+}
+
+// Words matched by the entries, with every rune repeated repeat times.
+func benchWords(repeat int) []string {
+	entries := benchEntries()
+	random := rand.New(rand.NewSource(3))
+	words := make([]string, benchWordCount)
+	for i := range words {
+		word := []rune{}
+		for _, r := range entries[random.Intn(len(entries))] {
+			if r == wildcard {
+				word = append(word, []rune(benchWord(random, 3))...)
+				continue
+			}
+			for j := 0; j <= repeat; j++ {
+				word = append(word, r)
+			}
+		}
+		words[i] = string(word)
+	}
+	return words
+}
+
+func benchWord(random *rand.Rand, length int) string {
+	alphabet := []rune(benchAlphabet)
+	word := make([]rune, length)
+	for i := range word {
+		word[i] = alphabet[random.Intn(len(alphabet))]
+	}
+	return string(word)
+}
+
+func benchDict(entries []string) *Dict {
+	dict := NewDict()
+	for _, entry := range entries {
+		dict.AddEntry(entry)
+	}
+	return dict
+}
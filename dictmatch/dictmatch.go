@@ -2,28 +2,75 @@ package dictmatch
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 )
 
 // Errors
 const (
-	Success                     = 0
-	WordOverlappedByWildcardErr = 1
-	WordExistErr                = 2
-	WildcardOverlappedByWordErr = 3
-	WildcardRootExistErr        = 4
-	InvalidWildardPlacementErr  = 5
+	Success                       = 0
+	WordOverlappedByWildcardErr   = 1
+	WordExistErr                  = 2
+	WildcardOverlappedByWordErr   = 3
+	WildcardRootExistErr          = 4
+	InvalidWildardPlacementErr    = 5
+	EntryNotExistErr              = 6
+	EntryOverlappedByExceptionErr = 7
+	ExceptionOverlapsEntryErr     = 8
+)
+
+// Match modes
+const (
+	// Repeated runes of a word are matched by a single rune of an entry.
+	CollapseRepeats = 1 << iota
+	// Diacritics are folded to base letters in both entries and words.
+	FoldDiacritics
 )
 
 // Node types
 const (
-	emptyNode    = 0
-	endNode      = 1
-	wildcardNode = 2
+	emptyNode = 0
+	endNode   = 1
 )
 
+const wildcard = '*'
+
+// Entries starting with ExceptionPrefix are exceptions. A word matched by an
+// exception is not matched by the dictionary, even if a broader entry
+// matches it. An exception cannot cover a whole entry and an entry cannot
+// be added if an exception covers it.
+const ExceptionPrefix = "!"
+
+// Entries starting with SubstringPrefix are substrings, found anywhere in
+// the text by Scan instead of being matched against whole words. They are
+// checked for overlaps as if they had wildcards on both ends.
+const SubstringPrefix = "~"
+
+// Entries containing whitespace are phrases, matched against consecutive
+// words by MatchPhrase. Only the last word of a phrase can have wildcards.
+const phraseSeparator = " "
+
+var diacriticFolds = map[rune]rune{
+	'ą': 'a', 'ć': 'c', 'ę': 'e', 'ł': 'l', 'ń': 'n', 'ó': 'o', 'ś': 's', 'ź': 'z', 'ż': 'z',
+	'Ą': 'A', 'Ć': 'C', 'Ę': 'E', 'Ł': 'L', 'Ń': 'N', 'Ó': 'O', 'Ś': 'S', 'Ź': 'Z', 'Ż': 'Z',
+}
+
+// AnyRune in a matched word stands for a single hidden rune (or none), so
+// masked words like "d?pa" can still be matched.
+const AnyRune = '?'
+
+// Dict can be read from many goroutines at once, but it must not be
+// modified while it is read. To change a shared dictionary, modify its
+// Clone and swap it in place of the original.
 type Dict struct {
-	tree *node
+	tree         *node
+	exceptions   *node
+	substrings   *node
+	scanner      *scanNode
+	scannerMutex sync.Mutex
+	phrases      *phraseNode
+	mode         int
 }
 
 type DictErr struct {
@@ -31,14 +78,52 @@ type DictErr struct {
 	ErrType int
 }
 
+// Wildcards are stored in the tree as regular '*' edges. Matching follows
+// a '*' edge after consuming any number of runes, so a wildcard can be
+// placed anywhere in the entry. Edges are kept in a slice sorted by rune,
+// which takes a fraction of the memory of a map for the few children most
+// nodes have.
 type node struct {
-	runeMap  map[rune]*node
+	edges    []edge
 	nodeType int
+	entry    string
+}
+
+type edge struct {
+	label rune
+	next  *node
+}
+
+// Visited (node, position) pairs, used to cut backtracking on wildcards.
+type visitKey struct {
+	current *node
+	index   int
 }
 
 func NewDict() *Dict {
+	return NewDictMode(0)
+}
+
+func NewDictMode(mode int) *Dict {
 	return &Dict{
-		tree: newNode(),
+		tree:       newNode(),
+		exceptions: newNode(),
+		substrings: newNode(),
+		scanner:    nil,
+		phrases:    newPhraseNode(),
+		mode:       mode,
+	}
+}
+
+// Clone returns a deep copy of the dictionary.
+func (dict *Dict) Clone() *Dict {
+	return &Dict{
+		tree:       cloneNode(dict.tree),
+		exceptions: cloneNode(dict.exceptions),
+		substrings: cloneNode(dict.substrings),
+		scanner:    nil,
+		phrases:    clonePhraseNode(dict.phrases),
+		mode:       dict.mode,
 	}
 }
 
@@ -53,73 +138,511 @@ func (dict *Dict) AddEntry(word string) *DictErr {
 	return nil
 }
 
+func (dict *Dict) RemoveEntry(word string) *DictErr {
+	root, runes := dict.entryRoot(word, false)
+	if root == dict.substrings {
+		dict.scanner = nil
+	}
+	if !removeRune(root, runes) {
+		return &DictErr{
+			Desc:    fmt.Sprintf("Error when removing '%s': %s", word, newDictErrDesc(EntryNotExistErr)),
+			ErrType: EntryNotExistErr,
+		}
+	}
+	return nil
+}
+
+// FindEntry returns the entry equal to the word, as it was added.
+func (dict *Dict) FindEntry(word string) (string, bool) {
+	found := findNode(dict.entryRoot(word, false))
+	if found == nil {
+		return "", false
+	}
+	return found.entry, true
+}
+
+// Entries returns all entries, as they were added, in sorted order.
+func (dict *Dict) Entries() []string {
+	entries := []string{}
+	collectEntries(dict.tree, &entries)
+	collectEntries(dict.exceptions, &entries)
+	collectEntries(dict.substrings, &entries)
+	collectPhraseEntries(dict.phrases, &entries)
+	sort.Strings(entries)
+	return entries
+}
+
+// Len returns the number of entries.
+func (dict *Dict) Len() int {
+	return countEntries(dict.tree) +
+		countEntries(dict.exceptions) +
+		countEntries(dict.substrings) +
+		countPhraseEntries(dict.phrases)
+}
+
+// Match returns whether the word is matched by any entry, together with
+// the matched entry stripped of its wildcards.
 func (dict *Dict) Match(word string) (bool, string) {
-	return matchRune(dict.tree, []rune(word), "")
+	runes := dict.toRunes(word)
+	if dict.isExcepted(runes) {
+		return false, ""
+	}
+	matcher := dict.newMatcher(runes)
+	if !matcher.matchRune(dict.tree, 0) {
+		return false, ""
+	}
+	return true, string(matcher.path)
+}
+
+// MatchEntry returns whether the word is matched by any entry, together
+// with the matching entry as it was added.
+func (dict *Dict) MatchEntry(word string) (bool, string) {
+	runes := dict.toRunes(word)
+	if dict.isExcepted(runes) {
+		return false, ""
+	}
+	matcher := dict.newMatcher(runes)
+	if !matcher.matchRune(dict.tree, 0) {
+		return false, ""
+	}
+	return true, matcher.end.entry
+}
+
+// Excepted returns whether the word is matched by an exception entry.
+func (dict *Dict) Excepted(word string) bool {
+	return dict.isExcepted(dict.toRunes(word))
+}
+
+func (dict *Dict) isExcepted(runes []rune) bool {
+	return dict.matchRunes(dict.exceptions, runes)
 }
 
 func (dict *Dict) addEntry(word string) int {
-	wildcards := strings.Count(word, "*")
-	if wildcards > 1 {
+	if isPhrase(word) && !dict.validPhrase(word) {
+		return InvalidWildardPlacementErr
+	}
+	root, runes := dict.entryRoot(word, true)
+	pattern := string(runes)
+	if strings.Contains(pattern, "**") || strings.Trim(pattern, "*") == "" {
 		return InvalidWildardPlacementErr
 	}
-	if wildcards == 1 && !strings.HasSuffix(word, "*") {
+	if root == dict.substrings && strings.ContainsRune(pattern[1:len(pattern)-1], wildcard) {
 		return InvalidWildardPlacementErr
 	}
-	return addRune(dict.tree, []rune(word))
+	errType := dict.checkOverlaps(root, pattern, runes)
+	if errType != Success {
+		return errType
+	}
+	if root == dict.exceptions {
+		if overlapsEntry(dict.tree, runes, 0, make(map[visitKey]bool)) {
+			return ExceptionOverlapsEntryErr
+		}
+	} else if root == dict.tree || root == dict.substrings {
+		if dict.isExcepted(runes) {
+			return EntryOverlappedByExceptionErr
+		}
+		other := dict.substrings
+		if root == dict.substrings {
+			other = dict.tree
+		}
+		if dict.matchRunes(other, runes) {
+			return WordOverlappedByWildcardErr
+		}
+		if overlapsEntry(other, runes, 0, make(map[visitKey]bool)) {
+			return WildcardOverlappedByWordErr
+		}
+	}
+	if root == dict.substrings {
+		dict.scanner = nil
+	}
+	addRune(root, runes, word)
+	return Success
 }
 
-func addRune(current *node, runes []rune) int {
-	if current.nodeType == wildcardNode {
+// Checks if the pattern overlaps or is overlapped by entries in the tree.
+// Words and phrases must not share any word with another entry, so every
+// word is matched by a single entry. Exceptions and substrings may share
+// words, as they are not used to tell which entry matched.
+func (dict *Dict) checkOverlaps(root *node, pattern string, runes []rune) int {
+	if findNode(root, runes) != nil {
+		if strings.ContainsRune(pattern, wildcard) {
+			return WordOverlappedByWildcardErr
+		}
+		return WordExistErr
+	}
+	if dict.matchRunes(root, runes) {
 		return WordOverlappedByWildcardErr
 	}
+	if strings.HasSuffix(pattern, "*") && findNode(root, runes[:len(runes)-1]) != nil {
+		return WildcardRootExistErr
+	}
+	if overlapsEntry(root, runes, 0, make(map[visitKey]bool)) {
+		return WildcardOverlappedByWordErr
+	}
+	if root != dict.exceptions && root != dict.substrings && sharesWord(root, runes) {
+		return WordOverlappedByWildcardErr
+	}
+	return Success
+}
+
+// Returns the tree the entry belongs to and the entry runes without the
+// prefix. Substring entries are wrapped in wildcards and for phrases the
+// tree of their last words is returned, created if create is set.
+func (dict *Dict) entryRoot(word string, create bool) (*node, []rune) {
+	if strings.HasPrefix(word, ExceptionPrefix) {
+		return dict.exceptions, dict.toRunes(strings.TrimPrefix(word, ExceptionPrefix))
+	}
+	if strings.HasPrefix(word, SubstringPrefix) {
+		substring := strings.TrimPrefix(word, SubstringPrefix)
+		return dict.substrings, dict.toRunes("*" + substring + "*")
+	}
+	if isPhrase(word) {
+		words := strings.Fields(word)
+		last := len(words) - 1
+		return dict.phraseRoot(words[:last], create), dict.toRunes(words[last])
+	}
+	return dict.tree, dict.toRunes(word)
+}
+
+func (dict *Dict) toRunes(word string) []rune {
+	runes := []rune(word)
+	if dict.mode&FoldDiacritics != 0 {
+		for i, r := range runes {
+			if folded, ok := diacriticFolds[r]; ok {
+				runes[i] = folded
+			}
+		}
+	}
+	return runes
+}
+
+func addRune(current *node, runes []rune, word string) {
+	for _, currentRune := range runes {
+		next := current.child(currentRune)
+		if next == nil {
+			next = current.addChild(currentRune)
+		}
+		current = next
+	}
+	current.nodeType = endNode
+	current.entry = word
+}
+
+// Unmarks the entry and prunes branches left without entries.
+func removeRune(current *node, runes []rune) bool {
 	if len(runes) == 0 {
-		if current.nodeType == endNode {
-			return WordExistErr
+		if current.nodeType != endNode {
+			return false
 		}
-		current.nodeType = endNode
-		return Success
+		current.nodeType = emptyNode
+		current.entry = ""
+		return true
 	}
-	currentRune := runes[0]
-	if currentRune == '*' {
-		if current.runeMap != nil {
-			return WildcardOverlappedByWordErr
+	next := current.child(runes[0])
+	if next == nil || !removeRune(next, runes[1:]) {
+		return false
+	}
+	if next.nodeType == emptyNode && len(next.edges) == 0 {
+		current.removeChild(runes[0])
+	}
+	return true
+}
+
+func collectEntries(current *node, entries *[]string) {
+	if current.nodeType == endNode {
+		*entries = append(*entries, current.entry)
+	}
+	for _, edge := range current.edges {
+		collectEntries(edge.next, entries)
+	}
+}
+
+func countEntries(current *node) int {
+	count := 0
+	if current.nodeType == endNode {
+		count++
+	}
+	for _, edge := range current.edges {
+		count += countEntries(edge.next)
+	}
+	return count
+}
+
+// Finds the end node of the exact entry or returns nil.
+func findNode(current *node, runes []rune) *node {
+	for _, currentRune := range runes {
+		current = current.child(currentRune)
+		if current == nil {
+			return nil
 		}
-		if current.nodeType == endNode {
-			return WildcardRootExistErr
+	}
+	if current.nodeType != endNode {
+		return nil
+	}
+	return current
+}
+
+func (dict *Dict) matchRunes(root *node, runes []rune) bool {
+	return dict.newMatcher(runes).matchRune(root, 0)
+}
+
+func (dict *Dict) newMatcher(runes []rune) *matcher {
+	return &matcher{
+		runes:    runes,
+		mode:     dict.mode,
+		path:     make([]rune, 0, len(runes)),
+		branches: dict.canBranch(runes),
+	}
+}
+
+// Returns whether matching the runes can reach the same node at the same
+// position in more than one way, regardless of wildcards in entries.
+func (dict *Dict) canBranch(runes []rune) bool {
+	for i, r := range runes {
+		if r == AnyRune {
+			return true
 		}
-		current.nodeType = wildcardNode
-		return Success
+		if dict.mode&CollapseRepeats != 0 && i > 0 && r == runes[i-1] {
+			return true
+		}
+	}
+	return false
+}
+
+// Matcher keeps the runes matched on the current path, so the matched
+// entry can be read from path once matching succeeds. Visited states are
+// remembered only once matching can branch, either on the word's own runes
+// or after a wildcard edge was followed.
+type matcher struct {
+	runes    []rune
+	mode     int
+	path     []rune
+	branches bool
+	visited  map[visitKey]bool
+	end      *node
+}
+
+// Matches runes against the tree. A '*' in runes is matched only by a
+// wildcard entry, which lets the same function check if a new wildcard
+// entry is already covered by an existing one.
+func (m *matcher) matchRune(current *node, index int) bool {
+	if m.branches {
+		key := visitKey{current, index}
+		if m.visited[key] {
+			return false
+		}
+		if m.visited == nil {
+			m.visited = make(map[visitKey]bool)
+		}
+		m.visited[key] = true
 	}
-	if current.runeMap == nil {
-		current.runeMap = make(map[rune]*node)
+	runes := m.runes
+	if index == len(runes) && current.nodeType == endNode {
+		m.end = current
+		return true
+	}
+	if index < len(runes) && runes[index] == AnyRune {
+		if m.matchRune(current, index+1) {
+			return true
+		}
+		for _, edge := range current.edges {
+			if edge.label != wildcard && m.matchNext(edge.next, edge.label, index) {
+				return true
+			}
+		}
+	} else if index < len(runes) && runes[index] != wildcard {
+		currentRune := runes[index]
+		next := current.child(currentRune)
+		if next != nil && m.matchNext(next, currentRune, index) {
+			return true
+		}
 	}
-	next := current.runeMap[currentRune]
-	if next == nil {
-		next = newNode()
-		current.runeMap[currentRune] = next
+	if star := current.child(wildcard); star != nil {
+		m.branches = true
+		for i := index; i <= len(runes); i++ {
+			if m.matchRune(star, i) {
+				return true
+			}
+		}
 	}
-	return addRune(next, runes[1:])
+	return false
 }
 
-func matchRune(current *node, runes []rune, matched string) (bool, string) {
-	if current.nodeType == wildcardNode {
-		return true, matched
+// Matches the rest of the word after the rune at index was matched by the
+// edge leading to next.
+func (m *matcher) matchNext(next *node, currentRune rune, index int) bool {
+	end := index + 1
+	if m.mode&CollapseRepeats != 0 {
+		for end < len(m.runes) && m.runes[end] == m.runes[index] {
+			end++
+		}
 	}
-	if len(runes) == 0 {
-		return current.nodeType == endNode, matched
+	m.path = append(m.path, currentRune)
+	for i := index + 1; i <= end; i++ {
+		if m.matchRune(next, i) {
+			return true
+		}
 	}
-	currentRune := runes[0]
-	next := current.runeMap[currentRune]
-	if next == nil {
-		return false, ""
+	m.path = m.path[:len(m.path)-1]
+	return false
+}
+
+// Checks if the pattern in runes matches any entry already in the tree,
+// i.e. if adding it would overlap an existing entry.
+func overlapsEntry(
+	current *node,
+	runes []rune,
+	index int,
+	visited map[visitKey]bool) bool {
+
+	key := visitKey{current, index}
+	if visited[key] {
+		return false
+	}
+	visited[key] = true
+	if index == len(runes) {
+		return current.nodeType == endNode
+	}
+	currentRune := runes[index]
+	if currentRune != wildcard {
+		next := current.child(currentRune)
+		return next != nil && overlapsEntry(next, runes, index+1, visited)
+	}
+	if index == len(runes)-1 && (current.nodeType == endNode || len(current.edges) > 0) {
+		return true
+	}
+	if overlapsEntry(current, runes, index+1, visited) {
+		return true
+	}
+	for _, edge := range current.edges {
+		if overlapsEntry(edge.next, runes, index, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// Visited states of sharesWord. Open is set while runes of the pattern are
+// consumed by a wildcard edge leading to current.
+type shareKey struct {
+	current *node
+	index   int
+	open    bool
+}
+
+// Checks if the pattern in runes and any entry in the tree match at least
+// one common word, which covers wildcard entries that only partly overlap,
+// like "a*" and "*ab".
+func sharesWord(root *node, runes []rune) bool {
+	return shareRune(root, runes, 0, false, make(map[shareKey]bool))
+}
+
+func shareRune(
+	current *node,
+	runes []rune,
+	index int,
+	open bool,
+	visited map[shareKey]bool) bool {
+
+	key := shareKey{current, index, open}
+	if visited[key] {
+		return false
+	}
+	visited[key] = true
+	if open {
+		if index < len(runes) && shareRune(current, runes, index+1, true, visited) {
+			return true
+		}
+		return shareRune(current, runes, index, false, visited)
+	}
+	if index == len(runes) && current.nodeType == endNode {
+		return true
+	}
+	if index < len(runes) && runes[index] == wildcard {
+		if shareRune(current, runes, index+1, false, visited) {
+			return true
+		}
+		for _, edge := range current.edges {
+			if edge.label != wildcard && shareRune(edge.next, runes, index, false, visited) {
+				return true
+			}
+		}
+	} else if index < len(runes) {
+		next := current.child(runes[index])
+		if next != nil && shareRune(next, runes, index+1, false, visited) {
+			return true
+		}
+	}
+	if star := current.child(wildcard); star != nil {
+		return shareRune(star, runes, index, true, visited)
+	}
+	return false
+}
+
+// Returns the child reached by the rune or nil.
+func (current *node) child(label rune) *node {
+	i := current.search(label)
+	if i < len(current.edges) && current.edges[i].label == label {
+		return current.edges[i].next
+	}
+	return nil
+}
+
+// Inserts a new child keeping the edges sorted and returns it.
+func (current *node) addChild(label rune) *node {
+	i := current.search(label)
+	next := newNode()
+	current.edges = append(current.edges, edge{})
+	copy(current.edges[i+1:], current.edges[i:])
+	current.edges[i] = edge{label, next}
+	return next
+}
+
+func (current *node) removeChild(label rune) {
+	i := current.search(label)
+	if i < len(current.edges) && current.edges[i].label == label {
+		current.edges = append(current.edges[:i], current.edges[i+1:]...)
+	}
+	if len(current.edges) == 0 {
+		current.edges = nil
+	}
+}
+
+// Returns the index of the first edge not less than the rune.
+func (current *node) search(label rune) int {
+	low, high := 0, len(current.edges)
+	for low < high {
+		middle := (low + high) / 2
+		if current.edges[middle].label < label {
+			low = middle + 1
+		} else {
+			high = middle
+		}
+	}
+	return low
+}
+
+func cloneNode(current *node) *node {
+	clone := &node{
+		edges:    nil,
+		nodeType: current.nodeType,
+		entry:    current.entry,
+	}
+	if len(current.edges) > 0 {
+		clone.edges = make([]edge, len(current.edges))
+		for i, edge := range current.edges {
+			clone.edges[i].label = edge.label
+			clone.edges[i].next = cloneNode(edge.next)
+		}
 	}
-	return matchRune(next, runes[1:], matched+string(currentRune))
+	return clone
 }
 
 func newNode() *node {
 	return &node{
-		runeMap:  nil,
+		edges:    nil,
 		nodeType: emptyNode,
+		entry:    "",
 	}
 }
 
@@ -134,7 +657,13 @@ func newDictErrDesc(errType int) string {
 	case WildcardRootExistErr:
 		return "This wildcard entry's root already exist."
 	case InvalidWildardPlacementErr:
-		return "Wildcard can be placed only at the end of the root word."
+		return "Wildcards cannot be adjacent or form the whole entry."
+	case EntryNotExistErr:
+		return "This entry does not exist."
+	case EntryOverlappedByExceptionErr:
+		return "This entry is overlapped by existing exception."
+	case ExceptionOverlapsEntryErr:
+		return "This exception overlaps existing entry."
 	default:
 		panic(fmt.Sprintf("Unknown error type: %d", errType))
 	}
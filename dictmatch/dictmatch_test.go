@@ -1,6 +1,7 @@
 package dictmatch
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -78,6 +79,178 @@ func TestMatchMultiple(t *testing.T) {
 	assertNotMatch(t, dict, "abc")
 }
 
+func TestMatchCollapseRepeats(t *testing.T) {
+	dict := NewDictMode(CollapseRepeats)
+	assertAddEntry(t, dict, "abc")
+	assertAddEntry(t, dict, "xyyz*")
+
+	assertMatch(t, dict, "aabbbc", "abc")
+	assertMatch(t, dict, "abcccc", "abc")
+	assertMatch(t, dict, "xyyyyz", "xyyz")
+	assertNotMatch(t, dict, "xyz")
+	assertNotMatch(t, dict, "abcd")
+}
+
+func TestMatchWithoutCollapseRepeats(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "abc")
+
+	assertNotMatch(t, dict, "aabc")
+	assertNotMatch(t, dict, "abcc")
+}
+
+func TestMatchFoldDiacritics(t *testing.T) {
+	dict := NewDictMode(FoldDiacritics)
+	assertAddEntry(t, dict, "zażółć")
+	assertAddEntry(t, dict, "gęś*")
+
+	assertMatch(t, dict, "zazolc", "zazolc")
+	assertMatch(t, dict, "zaźołć", "zazolc")
+	assertMatch(t, dict, "zażółć", "zazolc")
+	assertMatch(t, dict, "gesia", "ges")
+	assertMatch(t, dict, "gęsią", "ges")
+	assertNotMatch(t, dict, "zazol")
+	assertAddEntryError(t, dict, "zazolc", WordExistErr)
+	assertAddEntryError(t, dict, "gesi", WordOverlappedByWildcardErr)
+}
+
+func TestMatchWithoutFoldDiacritics(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "gęś")
+
+	assertNotMatch(t, dict, "ges")
+	assertAddEntry(t, dict, "ges")
+}
+
+func TestMatchAnyRune(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "abc")
+	assertAddEntry(t, dict, "xy*")
+
+	assertMatch(t, dict, "a?c", "abc")
+	assertMatch(t, dict, "?bc", "abc")
+	assertMatch(t, dict, "a?b?c", "abc")
+	assertMatch(t, dict, "x?", "xy")
+	assertNotMatch(t, dict, "a??c?d")
+	assertNotMatch(t, dict, "?")
+}
+
+func TestRemoveEntry(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "abc")
+	assertAddEntry(t, dict, "abcd*")
+	assertRemoveEntry(t, dict, "abcd*")
+
+	assertNotMatch(t, dict, "abcde")
+	assertMatch(t, dict, "abc", "abc")
+	assertRemoveEntry(t, dict, "abc")
+	assertNotMatch(t, dict, "abc")
+	assertAddEntry(t, dict, "ab*")
+	assertRemoveEntry(t, dict, "ab*")
+	assertAddEntryError(t, dict, "*", InvalidWildardPlacementErr)
+	assertAddEntryError(t, dict, "~*", InvalidWildardPlacementErr)
+	assertAddEntryError(t, dict, "!*", InvalidWildardPlacementErr)
+	assertAddEntry(t, dict, "a*")
+	assertAddEntryError(t, dict, "*", InvalidWildardPlacementErr)
+}
+
+func TestRemoveNotExistingEntry(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "abc*")
+	assertRemoveEntryError(t, dict, "abc", EntryNotExistErr)
+	assertRemoveEntryError(t, dict, "abcd", EntryNotExistErr)
+	assertRemoveEntryError(t, dict, "ab*", EntryNotExistErr)
+}
+
+func TestRemoveFoldedEntry(t *testing.T) {
+	dict := NewDictMode(FoldDiacritics)
+	assertAddEntry(t, dict, "gęś")
+	assertRemoveEntry(t, dict, "ges")
+	assertNotMatch(t, dict, "gęś")
+}
+
+func TestEntries(t *testing.T) {
+	dict := NewDictMode(FoldDiacritics)
+	assertEntries(t, dict, []string{})
+	assertAddEntry(t, dict, "b*y")
+	assertAddEntry(t, dict, "gęś")
+	assertAddEntry(t, dict, "a")
+	assertAddEntry(t, dict, "*x")
+	assertEntries(t, dict, []string{"*x", "a", "b*y", "gęś"})
+
+	entry, ok := dict.FindEntry("GĘŚ")
+	if ok {
+		t.Fatalf("Expected no entry for 'GĘŚ', got '%s'", entry)
+	}
+	entry, ok = dict.FindEntry("ges")
+	if !ok || entry != "gęś" {
+		t.Fatalf("Expected entry 'gęś' for 'ges', got '%s'", entry)
+	}
+}
+
+func TestMatchEntry(t *testing.T) {
+	dict := NewDictMode(FoldDiacritics)
+	assertAddEntry(t, dict, "ab*c")
+	assertAddEntry(t, dict, "gęś")
+	assertAddEntry(t, dict, "*x")
+
+	assertMatchEntry(t, dict, "abdc", "ab*c")
+	assertMatchEntry(t, dict, "ges", "gęś")
+	assertMatchEntry(t, dict, "yyx", "*x")
+	if ok, entry := dict.MatchEntry("yyy"); ok {
+		t.Fatalf("'yyy' should not be matched, got entry '%s'", entry)
+	}
+}
+
+func TestMatchException(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "abc*")
+	assertAddEntry(t, dict, "!abcd")
+	assertAddEntry(t, dict, "!abx*")
+	assertAddEntry(t, dict, "!*y")
+
+	assertMatch(t, dict, "abc", "abc")
+	assertMatch(t, dict, "abcde", "abc")
+	assertNotMatch(t, dict, "abcd")
+	assertNotMatch(t, dict, "abcy")
+	assertNotMatch(t, dict, "!abcd")
+	if ok, entry := dict.MatchEntry("abcd"); ok {
+		t.Fatalf("'abcd' should not be matched, got entry '%s'", entry)
+	}
+	assertEntries(t, dict, []string{"!*y", "!abcd", "!abx*", "abc*"})
+}
+
+func TestAddExceptionConflicts(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "abc*")
+	assertAddEntry(t, dict, "xyz")
+	assertAddEntry(t, dict, "!abcd*")
+
+	assertAddEntryError(t, dict, "!abcd*", WordOverlappedByWildcardErr)
+	assertAddEntryError(t, dict, "!abcde", WordOverlappedByWildcardErr)
+	assertAddEntryError(t, dict, "!abc*", WildcardOverlappedByWordErr)
+	assertAddEntryError(t, dict, "!xyz", ExceptionOverlapsEntryErr)
+	assertAddEntryError(t, dict, "!x*", ExceptionOverlapsEntryErr)
+	assertAddEntryError(t, dict, "abcdef", WordOverlappedByWildcardErr)
+	assertAddEntryError(t, dict, "!**", InvalidWildardPlacementErr)
+
+	dict = NewDict()
+	assertAddEntry(t, dict, "!abcd*")
+	assertAddEntryError(t, dict, "abcde", EntryOverlappedByExceptionErr)
+	assertAddEntry(t, dict, "abc*")
+}
+
+func TestRemoveException(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "abc*")
+	assertAddEntry(t, dict, "!abcd")
+	assertRemoveEntryError(t, dict, "abcd", EntryNotExistErr)
+	assertRemoveEntry(t, dict, "!abcd")
+
+	assertMatch(t, dict, "abcd", "abc")
+	assertRemoveEntryError(t, dict, "!abcd", EntryNotExistErr)
+}
+
 func TestAddDuplicatedEntry(t *testing.T) {
 	dict := NewDict()
 	assertAddEntry(t, dict, "abc")
@@ -108,13 +281,129 @@ func TestAddDuplicatedWildcard(t *testing.T) {
 	assertAddEntryError(t, dict, "abc*", WordOverlappedByWildcardErr)
 }
 
+func TestMatchPrefixWildcard(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "*abc")
+
+	assertNotMatch(t, dict, "ab")
+	assertNotMatch(t, dict, "abcd")
+	assertMatch(t, dict, "abc", "abc")
+	assertMatch(t, dict, "xabc", "abc")
+	assertMatch(t, dict, "abcabc", "abc")
+}
+
+func TestMatchInfixWildcard(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "ab*cd")
+
+	assertNotMatch(t, dict, "abc")
+	assertNotMatch(t, dict, "abcde")
+	assertMatch(t, dict, "abcd", "abcd")
+	assertMatch(t, dict, "abxxcd", "abcd")
+	assertMatch(t, dict, "abcdcd", "abcd")
+}
+
+func TestMatchMultipleWildcards(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "a*b*")
+	assertAddEntry(t, dict, "x*y")
+
+	assertNotMatch(t, dict, "ba")
+	assertNotMatch(t, dict, "xyz")
+	assertMatch(t, dict, "ab", "ab")
+	assertMatch(t, dict, "axbx", "ab")
+	assertMatch(t, dict, "xy", "xy")
+	assertMatch(t, dict, "xzzy", "xy")
+}
+
+func TestAddPartiallyOverlappingWildcards(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "ab*")
+	assertAddEntryError(t, dict, "*bc", WordOverlappedByWildcardErr)
+	assertAddEntryError(t, dict, "a*c", WordOverlappedByWildcardErr)
+	assertAddEntryError(t, dict, "*b*", WildcardOverlappedByWordErr)
+	assertAddEntry(t, dict, "b*c")
+
+	assertMatch(t, dict, "abc", "ab")
+	assertMatch(t, dict, "bxc", "bc")
+	assertNotMatch(t, dict, "xbc")
+}
+
+func TestAddWildcardsOverlappingWildcards(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "a*")
+	assertAddEntryError(t, dict, "*ab", WordOverlappedByWildcardErr)
+	assertAddEntryError(t, dict, "a*ab", WordOverlappedByWildcardErr)
+	assertAddEntryError(t, dict, "*a*ab", WordOverlappedByWildcardErr)
+
+	dict = NewDict()
+	assertAddEntry(t, dict, "a*c")
+	assertAddEntryError(t, dict, "*b*", WordOverlappedByWildcardErr)
+	assertAddEntryError(t, dict, "*c", WildcardOverlappedByWordErr)
+	assertAddEntryError(t, dict, "*b*c", WordOverlappedByWildcardErr)
+	assertAddEntry(t, dict, "b*c")
+	assertAddEntry(t, dict, "*cb")
+
+	dict = NewDict()
+	assertAddEntry(t, dict, "*b*")
+	assertAddEntryError(t, dict, "a*c", WordOverlappedByWildcardErr)
+	assertAddEntry(t, dict, "ac")
+	assertMatch(t, dict, "xbx", "b")
+	assertMatch(t, dict, "ac", "ac")
+}
+
+func TestAddWordOverlappedByPrefixWildcard(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "*abc")
+	assertAddEntryError(t, dict, "xabc", WordOverlappedByWildcardErr)
+	assertAddEntryError(t, dict, "x*abc", WordOverlappedByWildcardErr)
+	assertAddEntryError(t, dict, "*abc", WordOverlappedByWildcardErr)
+}
+
+func TestAddWildcardOverlappedByWordInfix(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "axxc")
+	assertAddEntry(t, dict, "b*c*d")
+	assertAddEntryError(t, dict, "a*c", WildcardOverlappedByWordErr)
+	assertAddEntryError(t, dict, "*c*", WildcardOverlappedByWordErr)
+	assertAddEntryError(t, dict, "b*d", WildcardOverlappedByWordErr)
+}
+
 func TestInvalidWildcardPlacement(t *testing.T) {
 	dict := NewDict()
 	assertAddEntryError(t, dict, "**a", InvalidWildardPlacementErr)
-	assertAddEntryError(t, dict, "*a", InvalidWildardPlacementErr)
-	assertAddEntryError(t, dict, "a*b", InvalidWildardPlacementErr)
 	assertAddEntryError(t, dict, "a**", InvalidWildardPlacementErr)
+	assertAddEntryError(t, dict, "a**b", InvalidWildardPlacementErr)
 	assertAddEntryError(t, dict, "**", InvalidWildardPlacementErr)
+	assertAddEntryError(t, dict, "*", InvalidWildardPlacementErr)
+	assertAddEntryError(t, dict, "~*", InvalidWildardPlacementErr)
+	assertAddEntryError(t, dict, "!*", InvalidWildardPlacementErr)
+	assertAddEntry(t, dict, "a*")
+	assertAddEntryError(t, dict, "*", InvalidWildardPlacementErr)
+}
+
+func TestClone(t *testing.T) {
+	dict := NewDictMode(CollapseRepeats)
+	assertAddEntry(t, dict, "abc")
+	assertAddEntry(t, dict, "x*z")
+	assertAddEntry(t, dict, "!xyz")
+	assertAddEntry(t, dict, "~kl")
+	assertAddEntry(t, dict, "ab cd*")
+	dict.Scan("kl")
+
+	clone := dict.Clone()
+	assertEntries(t, clone, []string{"!xyz", "ab cd*", "abc", "x*z", "~kl"})
+	assertMatch(t, clone, "aabc", "abc")
+	assertAddEntry(t, clone, "def")
+	assertAddEntry(t, clone, "~mn")
+	assertRemoveEntry(t, clone, "abc")
+	assertRemoveEntry(t, clone, "ab cd*")
+
+	assertEntries(t, dict, []string{"!xyz", "ab cd*", "abc", "x*z", "~kl"})
+	assertMatch(t, dict, "abc", "abc")
+	assertNotMatch(t, dict, "def")
+	assertScan(t, dict, "klmn", []Occurrence{Occurrence{"~kl", 0, 2}})
+	assertScan(t, clone, "klmn", []Occurrence{Occurrence{"~kl", 0, 2}, Occurrence{"~mn", 2, 4}})
 }
 
 func assertAddEntry(t *testing.T, dict *Dict, word string) {
@@ -155,3 +444,45 @@ func assertMatch(t *testing.T, dict *Dict, word string, expectedMatch string) {
 		t.Fatalf("Actual match '%s' is not equal to expected match '%s'", match, expectedMatch)
 	}
 }
+
+func assertRemoveEntry(t *testing.T, dict *Dict, word string) {
+	err := dict.RemoveEntry(word)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertRemoveEntryError(t *testing.T, dict *Dict, word string, errType int) {
+	err := dict.RemoveEntry(word)
+	if err == nil {
+		t.Fatalf("Removing entry '%s' should yield error type %d (no error found)", word, errType)
+	}
+	if err.ErrType != errType {
+		t.Fatalf(
+			"Removing entry '%s' should yield error type %d (error %d found instead: '%s')",
+			word,
+			errType,
+			err.ErrType,
+			err.Desc)
+	}
+}
+
+func assertEntries(t *testing.T, dict *Dict, expected []string) {
+	actual := dict.Entries()
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected entries %#v, got %#v", expected, actual)
+	}
+	if dict.Len() != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), dict.Len())
+	}
+}
+
+func assertMatchEntry(t *testing.T, dict *Dict, word string, expectedEntry string) {
+	success, entry := dict.MatchEntry(word)
+	if !success {
+		t.Fatalf("'%s' should be matched", word)
+	}
+	if entry != expectedEntry {
+		t.Fatalf("Actual entry '%s' is not equal to expected entry '%s'", entry, expectedEntry)
+	}
+}
@@ -0,0 +1,178 @@
+package dictmatch
+
+import (
+	"math/rand"
+	"runtime"
+	"testing"
+)
+
+// Baseline for the benchmarks: the trie as it was before edges were kept
+// in sorted slices, with a rune map in every node and the matched runes
+// prepended on every level. Only adding entries without overlap checks and
+// matching are kept, so compare it with BenchmarkDictMemory and the Match
+// benchmarks rather than BenchmarkAddEntries.
+type mapNode struct {
+	runeMap  map[rune]*mapNode
+	nodeType int
+	entry    string
+}
+
+type mapVisitKey struct {
+	current *mapNode
+	index   int
+}
+
+type mapMatcher struct {
+	runes   []rune
+	mode    int
+	visited map[mapVisitKey]bool
+}
+
+func addMapRune(current *mapNode, runes []rune, word string) {
+	for _, currentRune := range runes {
+		if current.runeMap == nil {
+			current.runeMap = make(map[rune]*mapNode)
+		}
+		next := current.runeMap[currentRune]
+		if next == nil {
+			next = &mapNode{}
+			current.runeMap[currentRune] = next
+		}
+		current = next
+	}
+	current.nodeType = endNode
+	current.entry = word
+}
+
+func matchMapTrie(root *mapNode, word string, mode int) ([]rune, bool) {
+	matcher := &mapMatcher{
+		runes:   []rune(word),
+		mode:    mode,
+		visited: make(map[mapVisitKey]bool),
+	}
+	return matcher.matchRune(root, 0)
+}
+
+func (m *mapMatcher) matchRune(current *mapNode, index int) ([]rune, bool) {
+	key := mapVisitKey{current, index}
+	if m.visited[key] {
+		return nil, false
+	}
+	m.visited[key] = true
+	runes := m.runes
+	if index == len(runes) && current.nodeType == endNode {
+		return []rune{}, true
+	}
+	if index < len(runes) && runes[index] != wildcard {
+		currentRune := runes[index]
+		next := current.runeMap[currentRune]
+		if next != nil {
+			if matched, ok := m.matchNext(next, currentRune, index); ok {
+				return matched, true
+			}
+		}
+	}
+	if star := current.runeMap[wildcard]; star != nil {
+		for i := index; i <= len(runes); i++ {
+			if matched, ok := m.matchRune(star, i); ok {
+				return matched, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func (m *mapMatcher) matchNext(next *mapNode, currentRune rune, index int) ([]rune, bool) {
+	end := index + 1
+	if m.mode&CollapseRepeats != 0 {
+		for end < len(m.runes) && m.runes[end] == m.runes[index] {
+			end++
+		}
+	}
+	for i := index + 1; i <= end; i++ {
+		if matched, ok := m.matchRune(next, i); ok {
+			return append([]rune{currentRune}, matched...), true
+		}
+	}
+	return nil, false
+}
+
+func benchMapTrie(entries []string) *mapNode {
+	root := &mapNode{}
+	for _, entry := range entries {
+		addMapRune(root, []rune(entry), entry)
+	}
+	return root
+}
+
+func BenchmarkMapTrieAddEntries(b *testing.B) {
+	entries := benchEntries()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		benchMapTrie(entries)
+	}
+}
+
+func BenchmarkMapTrieMemory(b *testing.B) {
+	entries := benchEntries()
+	var root *mapNode
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	for i := 0; i < b.N; i++ {
+		root = benchMapTrie(entries)
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(root)
+	perTrie := float64(after.TotalAlloc-before.TotalAlloc) / float64(b.N)
+	b.ReportMetric(perTrie/float64(len(entries)), "B/entry")
+	b.ReportMetric(float64(after.HeapInuse)/float64(len(entries)), "heap-B/entry")
+}
+
+func BenchmarkMapTrieMatch(b *testing.B) {
+	root := benchMapTrie(benchEntries())
+	words := benchWords(0)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		matchMapTrie(root, words[i%len(words)], 0)
+	}
+}
+
+func BenchmarkMapTrieMatchCollapse(b *testing.B) {
+	root := benchMapTrie(benchEntries())
+	words := benchWords(2)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		matchMapTrie(root, words[i%len(words)], CollapseRepeats)
+	}
+}
+
+func BenchmarkMapTrieMatchMiss(b *testing.B) {
+	root := benchMapTrie(benchEntries())
+	words := make([]string, benchWordCount)
+	random := rand.New(rand.NewSource(2))
+	for i := range words {
+		words[i] = benchWord(random, 12)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		matchMapTrie(root, words[i%len(words)], 0)
+	}
+}
+
+func TestMapTrieBaseline(t *testing.T) {
+	entries := []string{"abc", "xy*", "q*z"}
+	root := benchMapTrie(entries)
+	dict := benchDict(entries)
+	for _, word := range []string{"abc", "xyzz", "qwez", "xz", "a"} {
+		expected, _ := dict.Match(word)
+		if _, ok := matchMapTrie(root, word, 0); ok != expected {
+			t.Fatalf("Expected baseline trie to match '%s' like the dictionary", word)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package dictmatch
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Phrase tree node. Words leading to the node are compared exactly and
+// the last word of a phrase is matched against the node's word tree.
+type phraseNode struct {
+	next map[string]*phraseNode
+	last *node
+}
+
+// MatchPhrase returns the number of leading words matched by the longest
+// phrase entry, together with the entry as it was added. Zero is returned
+// if no phrase matches.
+func (dict *Dict) MatchPhrase(words []string) (int, string) {
+	length, entry := 0, ""
+	current := dict.phrases
+	for i, word := range words {
+		if i > 0 {
+			runes := dict.toRunes(word)
+			matcher := dict.newMatcher(runes)
+			if matcher.matchRune(current.last, 0) {
+				length, entry = i+1, matcher.end.entry
+			}
+		}
+		current = current.next[dict.toPhraseWord(word)]
+		if current == nil {
+			break
+		}
+	}
+	return length, entry
+}
+
+// Returns the word tree of the phrase node reached by the words. Missing
+// phrase nodes are created only if create is set, otherwise an empty
+// detached tree is returned.
+func (dict *Dict) phraseRoot(words []string, create bool) *node {
+	current := dict.phrases
+	for _, word := range words {
+		key := dict.toPhraseWord(word)
+		next := current.next[key]
+		if next == nil {
+			if !create {
+				return newNode()
+			}
+			next = newPhraseNode()
+			current.next[key] = next
+		}
+		current = next
+	}
+	return current.last
+}
+
+// Phrase must have at least two words and wildcards only in the last word.
+func (dict *Dict) validPhrase(phrase string) bool {
+	words := strings.Fields(phrase)
+	if len(words) < 2 || strings.HasPrefix(phrase, ExceptionPrefix) ||
+		strings.HasPrefix(phrase, SubstringPrefix) {
+
+		return false
+	}
+	for _, word := range words[:len(words)-1] {
+		if strings.ContainsRune(word, wildcard) {
+			return false
+		}
+	}
+	return true
+}
+
+// Converts a leading phrase word to its key, folded and collapsed the same
+// way as matched words.
+func (dict *Dict) toPhraseWord(word string) string {
+	runes := dict.toRunes(word)
+	if dict.mode&CollapseRepeats != 0 {
+		collapsed := make([]rune, 0, len(runes))
+		for i, r := range runes {
+			if i == 0 || r != runes[i-1] {
+				collapsed = append(collapsed, r)
+			}
+		}
+		runes = collapsed
+	}
+	return string(runes)
+}
+
+func isPhrase(word string) bool {
+	return strings.IndexFunc(strings.TrimSpace(word), unicode.IsSpace) >= 0
+}
+
+func collectPhraseEntries(current *phraseNode, entries *[]string) {
+	collectEntries(current.last, entries)
+	for _, next := range current.next {
+		collectPhraseEntries(next, entries)
+	}
+}
+
+func countPhraseEntries(current *phraseNode) int {
+	count := countEntries(current.last)
+	for _, next := range current.next {
+		count += countPhraseEntries(next)
+	}
+	return count
+}
+
+func clonePhraseNode(current *phraseNode) *phraseNode {
+	clone := &phraseNode{
+		next: make(map[string]*phraseNode, len(current.next)),
+		last: cloneNode(current.last),
+	}
+	for word, next := range current.next {
+		clone.next[word] = clonePhraseNode(next)
+	}
+	return clone
+}
+
+func newPhraseNode() *phraseNode {
+	return &phraseNode{
+		next: map[string]*phraseNode{},
+		last: newNode(),
+	}
+}
@@ -0,0 +1,74 @@
+package dictmatch
+
+import (
+	"testing"
+)
+
+func TestMatchPhrase(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "ab cd")
+	assertAddEntry(t, dict, "ab cd ef*")
+	assertAddEntry(t, dict, "xy *z")
+
+	assertMatchPhrase(t, dict, []string{"ab", "cd"}, 2, "ab cd")
+	assertMatchPhrase(t, dict, []string{"ab", "cd", "x"}, 2, "ab cd")
+	assertMatchPhrase(t, dict, []string{"ab", "cd", "efgh", "x"}, 3, "ab cd ef*")
+	assertMatchPhrase(t, dict, []string{"xy", "zzz"}, 2, "xy *z")
+	assertMatchPhrase(t, dict, []string{"ab"}, 0, "")
+	assertMatchPhrase(t, dict, []string{"ab", "c"}, 0, "")
+	assertMatchPhrase(t, dict, []string{"x", "ab", "cd"}, 0, "")
+	assertMatchPhrase(t, dict, []string{}, 0, "")
+	assertNotMatch(t, dict, "ab")
+	assertNotMatch(t, dict, "ab cd")
+}
+
+func TestMatchPhraseModes(t *testing.T) {
+	dict := NewDictMode(FoldDiacritics | CollapseRepeats)
+	assertAddEntry(t, dict, "ja pierdolę")
+
+	assertMatchPhrase(t, dict, []string{"jaaa", "pierdole"}, 2, "ja pierdolę")
+	assertAddEntryError(t, dict, "ja pierdole", WordExistErr)
+}
+
+func TestAddPhraseConflicts(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "ab cd")
+	assertAddEntry(t, dict, "ab ef*")
+
+	assertAddEntryError(t, dict, "ab  cd", WordExistErr)
+	assertAddEntryError(t, dict, "ab efg", WordOverlappedByWildcardErr)
+	assertAddEntryError(t, dict, "ab c*", WildcardOverlappedByWordErr)
+	assertAddEntryError(t, dict, "ab cd*", WildcardRootExistErr)
+	assertAddEntryError(t, dict, "a* cd", InvalidWildardPlacementErr)
+	assertAddEntryError(t, dict, "xy *", InvalidWildardPlacementErr)
+	assertAddEntryError(t, dict, "!ab cd", InvalidWildardPlacementErr)
+	assertAddEntryError(t, dict, "~ab cd", InvalidWildardPlacementErr)
+	assertAddEntry(t, dict, "cd")
+	assertAddEntry(t, dict, "ab cd ef")
+}
+
+func TestRemovePhrase(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "ab cd")
+	assertAddEntry(t, dict, "ab cd ef")
+	assertEntries(t, dict, []string{"ab cd", "ab cd ef"})
+
+	assertRemoveEntryError(t, dict, "ab ef", EntryNotExistErr)
+	assertRemoveEntry(t, dict, "ab cd")
+	assertMatchPhrase(t, dict, []string{"ab", "cd", "ef"}, 3, "ab cd ef")
+	assertMatchPhrase(t, dict, []string{"ab", "cd"}, 0, "")
+	assertEntries(t, dict, []string{"ab cd ef"})
+}
+
+func assertMatchPhrase(t *testing.T, dict *Dict, words []string, expectedLength int, expectedEntry string) {
+	length, entry := dict.MatchPhrase(words)
+	if length != expectedLength || entry != expectedEntry {
+		t.Fatalf(
+			"Expected phrase '%s' of length %d matched in %#v, got '%s' of length %d",
+			expectedEntry,
+			expectedLength,
+			words,
+			entry,
+			length)
+	}
+}
@@ -0,0 +1,157 @@
+package dictmatch
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// Occurrence of a substring entry in the scanned text. Start and End are
+// byte offsets in the text.
+type Occurrence struct {
+	Entry string
+	Start int
+	End   int
+}
+
+type ByOccurrenceStart []Occurrence
+
+func (a ByOccurrenceStart) Len() int {
+	return len(a)
+}
+
+func (a ByOccurrenceStart) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
+}
+
+func (a ByOccurrenceStart) Less(i, j int) bool {
+	if a[i].Start == a[j].Start {
+		return a[i].End > a[j].End
+	}
+	return a[i].Start < a[j].Start
+}
+
+// Aho-Corasick automaton node. Outputs contain entries ending in this node
+// and in all nodes reachable by fail links.
+type scanNode struct {
+	next    map[rune]*scanNode
+	fail    *scanNode
+	outputs []scanOutput
+}
+
+type scanOutput struct {
+	entry  string
+	length int
+}
+
+// Scanned rune with its position in the original text.
+type scanRune struct {
+	value rune
+	start int
+	end   int
+}
+
+// Scan finds all occurrences of substring entries in the text in a single
+// pass, sorted by start offset.
+func (dict *Dict) Scan(text string) []Occurrence {
+	scanner := dict.getScanner()
+	occurrences := []Occurrence{}
+	runes := dict.toScanRunes(text)
+	current := scanner
+	for i, r := range runes {
+		for current != scanner && current.next[r.value] == nil {
+			current = current.fail
+		}
+		if next := current.next[r.value]; next != nil {
+			current = next
+		}
+		for _, output := range current.outputs {
+			occurrences = append(occurrences, Occurrence{
+				Entry: output.entry,
+				Start: runes[i-output.length+1].start,
+				End:   r.end,
+			})
+		}
+	}
+	sort.Sort(ByOccurrenceStart(occurrences))
+	return occurrences
+}
+
+// Returns the automaton, built on first use. Concurrent scans wait for
+// the automaton to be built once.
+func (dict *Dict) getScanner() *scanNode {
+	dict.scannerMutex.Lock()
+	defer dict.scannerMutex.Unlock()
+	if dict.scanner == nil {
+		dict.scanner = dict.buildScanner()
+	}
+	return dict.scanner
+}
+
+func (dict *Dict) buildScanner() *scanNode {
+	root := newScanNode()
+	entries := []string{}
+	collectEntries(dict.substrings, &entries)
+	for _, entry := range entries {
+		current := root
+		runes := dict.toScanRunes(entry[len(SubstringPrefix):])
+		for _, r := range runes {
+			next := current.next[r.value]
+			if next == nil {
+				next = newScanNode()
+				current.next[r.value] = next
+			}
+			current = next
+		}
+		current.outputs = append(current.outputs, scanOutput{entry, len(runes)})
+	}
+	queue := []*scanNode{}
+	for _, next := range root.next {
+		next.fail = root
+		queue = append(queue, next)
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for r, next := range current.next {
+			fail := current.fail
+			for fail != root && fail.next[r] == nil {
+				fail = fail.fail
+			}
+			if target := fail.next[r]; target != nil {
+				next.fail = target
+			} else {
+				next.fail = root
+			}
+			next.outputs = append(next.outputs, next.fail.outputs...)
+			queue = append(queue, next)
+		}
+	}
+	return root
+}
+
+// Converts text to runes the same way entries are stored. Repeated runes
+// are skipped in CollapseRepeats mode, so their positions span the repeats.
+func (dict *Dict) toScanRunes(text string) []scanRune {
+	folded := dict.toRunes(text)
+	runes := make([]scanRune, 0, len(folded))
+	offset := 0
+	for _, r := range folded {
+		_, width := utf8.DecodeRuneInString(text[offset:])
+		last := len(runes) - 1
+		if dict.mode&CollapseRepeats != 0 && last >= 0 && runes[last].value == r {
+			runes[last].end = offset + width
+		} else {
+			runes = append(runes, scanRune{value: r, start: offset, end: offset + width})
+		}
+		offset += width
+	}
+	return runes
+}
+
+func newScanNode() *scanNode {
+	return &scanNode{
+		next:    map[rune]*scanNode{},
+		fail:    nil,
+		outputs: []scanOutput{},
+	}
+}
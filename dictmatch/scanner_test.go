@@ -0,0 +1,90 @@
+package dictmatch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScanEmpty(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "abc")
+	assertScan(t, dict, "abc", []Occurrence{})
+}
+
+func TestScan(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "~abc")
+	assertAddEntry(t, dict, "~bcx")
+	assertAddEntry(t, dict, "~xa")
+
+	expected := []Occurrence{
+		Occurrence{Entry: "~abc", Start: 1, End: 4},
+		Occurrence{Entry: "~abc", Start: 5, End: 8},
+		Occurrence{Entry: "~bcx", Start: 6, End: 9},
+		Occurrence{Entry: "~xa", Start: 8, End: 10},
+		Occurrence{Entry: "~abc", Start: 9, End: 12},
+	}
+	assertScan(t, dict, " abc abcxabc ab", expected)
+}
+
+func TestScanUnicode(t *testing.T) {
+	dict := NewDictMode(FoldDiacritics)
+	assertAddEntry(t, dict, "~żół")
+
+	expected := []Occurrence{
+		Occurrence{Entry: "~żół", Start: 4, End: 10},
+		Occurrence{Entry: "~żół", Start: 10, End: 13},
+	}
+	assertScan(t, dict, "ταżółzol", expected)
+	assertScan(t, dict, "ταżół", expected[:1])
+}
+
+func TestScanCollapseRepeats(t *testing.T) {
+	dict := NewDictMode(CollapseRepeats)
+	assertAddEntry(t, dict, "~abba")
+
+	expected := []Occurrence{
+		Occurrence{Entry: "~abba", Start: 1, End: 7},
+	}
+	assertScan(t, dict, "xaabbbax", expected)
+}
+
+func TestScanAfterRemove(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "~abc")
+	assertScan(t, dict, "abc", []Occurrence{Occurrence{Entry: "~abc", Start: 0, End: 3}})
+	assertRemoveEntry(t, dict, "~abc")
+	assertScan(t, dict, "abc", []Occurrence{})
+	assertEntries(t, dict, []string{})
+}
+
+func TestAddSubstringConflicts(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "~bcd")
+	assertAddEntryError(t, dict, "*c*", WildcardOverlappedByWordErr)
+	assertAddEntry(t, dict, "xyz*")
+
+	assertAddEntryError(t, dict, "~bcd", WordOverlappedByWildcardErr)
+	assertAddEntryError(t, dict, "~abcde", WordOverlappedByWildcardErr)
+	assertAddEntryError(t, dict, "~c", WildcardOverlappedByWordErr)
+	assertAddEntryError(t, dict, "abcde", WordOverlappedByWildcardErr)
+	assertAddEntryError(t, dict, "*c*", WordOverlappedByWildcardErr)
+	assertAddEntryError(t, dict, "~y", WildcardOverlappedByWordErr)
+	assertAddEntryError(t, dict, "~a*b", InvalidWildardPlacementErr)
+	assertAddEntryError(t, dict, "~", InvalidWildardPlacementErr)
+	assertAddEntry(t, dict, "~xyzz")
+	assertEntries(t, dict, []string{"xyz*", "~bcd", "~xyzz"})
+}
+
+func TestSubstringsNotMatched(t *testing.T) {
+	dict := NewDict()
+	assertAddEntry(t, dict, "~abc")
+	assertNotMatch(t, dict, "abc")
+}
+
+func assertScan(t *testing.T, dict *Dict, text string, expected []Occurrence) {
+	actual := dict.Scan(text)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected occurrences %#v in '%s', got %#v", expected, text, actual)
+	}
+}
@@ -0,0 +1,217 @@
+package kvstore
+
+import (
+	"../utils"
+	"bufio"
+	"encoding/json"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	Success          = 0
+	StoreFileReadErr = 51
+	StoreSaveErr     = 52
+)
+
+// Log is compacted once it holds this many times more records than there
+// are live keys.
+const compactRatio = 4
+
+// Store is a key-value store kept in memory and persisted in a single file.
+// Every write is appended to the file as one batch, so a batch is either
+// written whole or not at all. The file is rewritten with live keys only
+// when it grows too large.
+type Store struct {
+	mutex    sync.RWMutex
+	fileName string
+	file     *os.File
+	values   map[string]string
+	records  int
+}
+
+// Batch of changes written at once. Deletions are applied after sets.
+type Batch struct {
+	Set    map[string]string `json:",omitempty"`
+	Delete []string          `json:",omitempty"`
+}
+
+func NewBatch() *Batch {
+	return &Batch{
+		Set:    map[string]string{},
+		Delete: []string{},
+	}
+}
+
+// Open reads the store file, creating it if it does not exist.
+func Open(fileName string) (*Store, int) {
+	store := &Store{
+		fileName: fileName,
+		values:   map[string]string{},
+	}
+	broken, err := store.read()
+	if err != Success {
+		return nil, err
+	}
+	file, openErr := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if openErr != nil {
+		log.Printf("KvStore: cannot open store file '%s': %v\n", fileName, openErr)
+		return nil, StoreFileReadErr
+	}
+	store.file = file
+	if broken && store.compact() != Success {
+		file.Close()
+		return nil, StoreFileReadErr
+	}
+	return store, Success
+}
+
+func (store *Store) Get(key string) (string, bool) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	value, ok := store.values[key]
+	return value, ok
+}
+
+func (store *Store) Put(key string, value string) int {
+	batch := NewBatch()
+	batch.Set[key] = value
+	return store.Write(batch)
+}
+
+func (store *Store) Remove(key string) int {
+	batch := NewBatch()
+	batch.Delete = append(batch.Delete, key)
+	return store.Write(batch)
+}
+
+// Write appends the batch to the store file and applies it.
+func (store *Store) Write(batch *Batch) int {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.file == nil {
+		log.Printf("KvStore: store file '%s' is closed\n", store.fileName)
+		return StoreSaveErr
+	}
+	bytes, _ := json.Marshal(batch)
+	_, err := store.file.Write(append(bytes, '\n'))
+	if err != nil {
+		log.Printf("KvStore: cannot write to store file '%s': %v\n", store.fileName, err)
+		return StoreSaveErr
+	}
+	store.apply(batch)
+	store.records++
+	if store.records > compactRatio*len(store.values)+compactRatio {
+		store.compact()
+	}
+	return Success
+}
+
+// Scan calls action for every key with the prefix, in key order.
+func (store *Store) Scan(prefix string, action func(key string, value string)) {
+	store.mutex.RLock()
+	keys := []string{}
+	values := map[string]string{}
+	for key, value := range store.values {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+			values[key] = value
+		}
+	}
+	store.mutex.RUnlock()
+	sort.Strings(keys)
+	for _, key := range keys {
+		action(key, values[key])
+	}
+}
+
+func (store *Store) Len() int {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return len(store.values)
+}
+
+// Compact rewrites the store file with live keys only.
+func (store *Store) Compact() int {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.compact()
+}
+
+func (store *Store) Close() int {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.file == nil {
+		return Success
+	}
+	err := store.file.Close()
+	store.file = nil
+	if err != nil {
+		log.Printf("KvStore: cannot close store file '%s': %v\n", store.fileName, err)
+		return StoreSaveErr
+	}
+	return Success
+}
+
+func (store *Store) compact() int {
+	batch := NewBatch()
+	batch.Set = store.values
+	bytes, _ := json.Marshal(batch)
+	err := utils.WriteFileAtomic(store.fileName, append(bytes, '\n'))
+	if err != nil {
+		return StoreSaveErr
+	}
+	file, openErr := os.OpenFile(store.fileName, os.O_WRONLY|os.O_APPEND, 0666)
+	if openErr != nil {
+		log.Printf("KvStore: cannot reopen store file '%s': %v\n", store.fileName, openErr)
+		return StoreSaveErr
+	}
+	store.file.Close()
+	store.file = file
+	store.records = 1
+	return Success
+}
+
+// Reads batches from the store file. Reading stops at the first broken
+// batch, which can only be the last one, written partially. Returns true
+// if the last batch is broken or not terminated, the file has to be
+// compacted before anything is appended to it then.
+func (store *Store) read() (bool, int) {
+	file, err := os.Open(store.fileName)
+	if os.IsNotExist(err) {
+		return false, Success
+	}
+	if err != nil {
+		log.Printf("KvStore: cannot open store file '%s': %v\n", store.fileName, err)
+		return false, StoreFileReadErr
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			batch := &Batch{}
+			if err := json.Unmarshal(line, batch); err != nil {
+				log.Printf("KvStore: skipping broken batch in store file '%s'\n", store.fileName)
+				return true, Success
+			}
+			store.apply(batch)
+			store.records++
+		}
+		if readErr != nil {
+			return len(line) > 0, Success
+		}
+	}
+}
+
+func (store *Store) apply(batch *Batch) {
+	for key, value := range batch.Set {
+		store.values[key] = value
+	}
+	for _, key := range batch.Delete {
+		delete(store.values, key)
+	}
+}
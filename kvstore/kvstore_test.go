@@ -0,0 +1,178 @@
+package kvstore
+
+import (
+	"../utils"
+	"io/ioutil"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func init() {
+	log.SetOutput(ioutil.Discard)
+}
+
+func TestPutGet(t *testing.T) {
+	fileName := createTmpStorePath(t)
+	defer os.Remove(fileName)
+
+	store := openStore(t, fileName)
+	assertPut(t, store, "key1", "val1")
+	assertPut(t, store, "key2", "val2")
+	assertPut(t, store, "key1", "val3")
+	assertGet(t, store, "key1", "val3", true)
+	assertGet(t, store, "key2", "val2", true)
+	assertGet(t, store, "key3", "", false)
+}
+
+func TestReopen(t *testing.T) {
+	fileName := createTmpStorePath(t)
+	defer os.Remove(fileName)
+
+	store := openStore(t, fileName)
+	assertPut(t, store, "key1", "val1")
+	assertPut(t, store, "key2", "val2")
+	if err := store.Remove("key1"); err != Success {
+		t.Fatalf("Expected no error when removing key, got %v", err)
+	}
+	store.Close()
+
+	store = openStore(t, fileName)
+	assertGet(t, store, "key1", "", false)
+	assertGet(t, store, "key2", "val2", true)
+}
+
+func TestWriteBatch(t *testing.T) {
+	fileName := createTmpStorePath(t)
+	defer os.Remove(fileName)
+
+	store := openStore(t, fileName)
+	assertPut(t, store, "key1", "val1")
+	batch := NewBatch()
+	batch.Set["key2"] = "val2"
+	batch.Set["key3"] = "val3"
+	batch.Delete = append(batch.Delete, "key1", "key3")
+	if err := store.Write(batch); err != Success {
+		t.Fatalf("Expected no error when writing batch, got %v", err)
+	}
+	assertScan(t, store, "", map[string]string{"key2": "val2"})
+}
+
+func TestScanPrefix(t *testing.T) {
+	fileName := createTmpStorePath(t)
+	defer os.Remove(fileName)
+
+	store := openStore(t, fileName)
+	assertPut(t, store, "b/2", "val2")
+	assertPut(t, store, "a/1", "val1")
+	assertPut(t, store, "b/1", "val3")
+	keys := []string{}
+	store.Scan("b/", func(key string, value string) {
+		keys = append(keys, key)
+	})
+	expected := []string{"b/1", "b/2"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("Expected keys %v, got %v", expected, keys)
+	}
+}
+
+func TestBrokenLastBatch(t *testing.T) {
+	fileName := createTmpStorePath(t)
+	defer os.Remove(fileName)
+
+	content := `{"Set":{"key1":"val1"}}` + "\n" + `{"Set":{"key2":"va`
+	if err := ioutil.WriteFile(fileName, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+	store := openStore(t, fileName)
+	assertScan(t, store, "", map[string]string{"key1": "val1"})
+	assertPut(t, store, "key3", "val3")
+	store.Close()
+
+	store = openStore(t, fileName)
+	assertScan(t, store, "", map[string]string{"key1": "val1", "key3": "val3"})
+}
+
+func TestCompact(t *testing.T) {
+	fileName := createTmpStorePath(t)
+	defer os.Remove(fileName)
+
+	store := openStore(t, fileName)
+	for i := 0; i < 20; i++ {
+		assertPut(t, store, "key1", "val1")
+	}
+	assertPut(t, store, "key2", "val2")
+	if err := store.Compact(); err != Success {
+		t.Fatalf("Expected no error when compacting, got %v", err)
+	}
+	assertPut(t, store, "key3", "val3")
+	assertFileLines(t, fileName, 2)
+	store.Close()
+
+	store = openStore(t, fileName)
+	expected := map[string]string{"key1": "val1", "key2": "val2", "key3": "val3"}
+	assertScan(t, store, "", expected)
+}
+
+func TestAutoCompact(t *testing.T) {
+	fileName := createTmpStorePath(t)
+	defer os.Remove(fileName)
+
+	store := openStore(t, fileName)
+	for i := 0; i < 20; i++ {
+		assertPut(t, store, "key1", "val1")
+	}
+	assertFileLines(t, fileName, 4)
+}
+
+func createTmpStorePath(t *testing.T) string {
+	fileName := utils.CreateTmpFileName("KvStore")
+	if fileName == "" {
+		t.Fatal("Cannot create temp store file path")
+	}
+	return fileName
+}
+
+func openStore(t *testing.T, fileName string) *Store {
+	store, err := Open(fileName)
+	if err != Success {
+		t.Fatalf("Expected to open store without errors, got %v", err)
+	}
+	return store
+}
+
+func assertPut(t *testing.T, store *Store, key string, value string) {
+	if err := store.Put(key, value); err != Success {
+		t.Fatalf("Expected no error when putting key '%s', got %v", key, err)
+	}
+}
+
+func assertGet(t *testing.T, store *Store, key string, expected string, expectedOk bool) {
+	actual, ok := store.Get(key)
+	if actual != expected || ok != expectedOk {
+		t.Fatalf("Expected '%s' (%v) for key '%s', got '%s' (%v)", expected, expectedOk, key, actual, ok)
+	}
+}
+
+func assertScan(t *testing.T, store *Store, prefix string, expected map[string]string) {
+	actual := map[string]string{}
+	store.Scan(prefix, func(key string, value string) {
+		actual[key] = value
+	})
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected values %v, got %v", expected, actual)
+	}
+}
+
+func assertFileLines(t *testing.T, fileName string, expected int) {
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := strings.Count(string(content), "\n")
+	if actual != expected {
+		t.Fatalf("Expected %d lines in store file, got %d", expected, actual)
+	}
+}
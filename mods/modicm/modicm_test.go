@@ -113,8 +113,10 @@ func newTestConfig() *ModIcmConfig {
 }
 
 func newTestState(t *testing.T, settingsFilePath string) mods.State {
+	usersFilePath := settingsFilePath + ".users"
+	defer os.Remove(usersFilePath)
 	state := mods.NewState(nil, testAsyncChan)
-	if !state.Init(settingsFilePath) {
+	if !state.Init(settingsFilePath, usersFilePath) {
 		t.Fatal("Cannot init mod state")
 	}
 	return state
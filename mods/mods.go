@@ -15,6 +15,7 @@ const (
 	Success           = 0
 	ModsDirName       = "mods"
 	SettingsFileName  = "settings.json"
+	UsersFileName     = "users.json"
 	ModConfigFileName = "config.json"
 )
 
@@ -25,6 +26,26 @@ type Mod interface {
 	ProcessMessage(message string, userId string, channelId string) *Response
 }
 
+// Mods keeping state in memory implement Closer to persist it when the
+// bot shuts down.
+type Closer interface {
+	Close()
+}
+
+// Mods which need to know which message they process implement
+// MessageTsProcessor, ProcessMessageTs is called instead of ProcessMessage
+// then. Message timestamp identifies the message within its channel.
+type MessageTsProcessor interface {
+	ProcessMessageTs(message string, userId string, channelId string, messageTs string) *Response
+}
+
+// Mods which count something in messages implement MessageChangeProcessor
+// to recount edited messages and to forget deleted ones.
+type MessageChangeProcessor interface {
+	ProcessMessageEdit(message string, userId string, channelId string, messageTs string) *Response
+	ProcessMessageDelete(channelId string, messageTs string) *Response
+}
+
 type Response struct {
 	Message   string
 	ChannelId string
@@ -32,6 +53,7 @@ type Response struct {
 
 type ModContainer struct {
 	modInfos      []*ModInfo
+	state         *state
 	AsyncResponse chan Response
 }
 
@@ -83,12 +105,12 @@ func (mc *ModContainer) AddMod(mod Mod) bool {
 }
 
 func (mc *ModContainer) InitMods(slackClient *slack.Client) bool {
-	settingsFilePath := getSettingsFilePath()
 	state := NewState(slackClient, mc.AsyncResponse)
-	if !state.Init(settingsFilePath) {
+	if !state.Init(getSettingsFilePath(), getUsersFilePath()) {
 		log.Println("ModContainer: mod state failed to initialize")
 		return false
 	}
+	mc.state = state
 	modsRegistered := []string{}
 	modsEnabled := []string{}
 	modsInitialized := []string{}
@@ -137,14 +159,61 @@ func (mc *ModContainer) ProcessMention(
 func (mc *ModContainer) ProcessMessage(
 	message string,
 	userId string,
-	channelId string) *Response {
+	channelId string,
+	messageTs string) *Response {
 
 	return mc.executeOnActiveMod(func(mod Mod) *Response {
 		defer recoverMod("ProcessMessage", mod.Name(), message, userId, channelId)
+		if processor, ok := mod.(MessageTsProcessor); ok {
+			return processor.ProcessMessageTs(message, userId, channelId, messageTs)
+		}
 		return mod.ProcessMessage(message, userId, channelId)
 	})
 }
 
+func (mc *ModContainer) ProcessMessageEdit(
+	message string,
+	userId string,
+	channelId string,
+	messageTs string) *Response {
+
+	return mc.executeOnActiveMod(func(mod Mod) *Response {
+		defer recoverMod("ProcessMessageEdit", mod.Name(), message, userId, channelId)
+		if processor, ok := mod.(MessageChangeProcessor); ok {
+			return processor.ProcessMessageEdit(message, userId, channelId, messageTs)
+		}
+		return nil
+	})
+}
+
+func (mc *ModContainer) ProcessMessageDelete(channelId string, messageTs string) *Response {
+	return mc.executeOnActiveMod(func(mod Mod) *Response {
+		defer recoverMod("ProcessMessageDelete", mod.Name(), "", "", channelId)
+		if processor, ok := mod.(MessageChangeProcessor); ok {
+			return processor.ProcessMessageDelete(channelId, messageTs)
+		}
+		return nil
+	})
+}
+
+// UpdateUsers replaces users in the user directory of mods.
+func (mc *ModContainer) UpdateUsers(users []slack.User) {
+	mc.state.users.SetUsers(users)
+}
+
+// UpdateUser adds a new or changed user to the user directory of mods.
+func (mc *ModContainer) UpdateUser(user slack.User) {
+	mc.state.users.SetUser(user)
+}
+
+func (mc *ModContainer) CloseMods() {
+	for _, modInfo := range mc.modInfos {
+		if closer, ok := modInfo.Instance.(Closer); ok && modInfo.Active {
+			closer.Close()
+		}
+	}
+}
+
 func GetPath(mod Mod, fileName string) string {
 	return path.Join(getModDirPath(mod), fileName)
 }
@@ -201,3 +270,7 @@ func getModConfigFilePath() string {
 func getSettingsFilePath() string {
 	return path.Join(ModsDirName, SettingsFileName)
 }
+
+func getUsersFilePath() string {
+	return path.Join(ModsDirName, UsersFileName)
+}
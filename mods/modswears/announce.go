@@ -0,0 +1,93 @@
+package modswears
+
+import (
+	"../../mods"
+	"../../settings"
+	"../../utils"
+	"log"
+	"time"
+)
+
+const (
+	SettingAnnounceRank       = "ModSwears.AnnounceRank"
+	SettingLastAnnouncedMonth = "ModSwears.LastAnnouncedMonth"
+)
+
+// Longest sleep between checks, so a changed system clock delays the
+// announcement by an hour at most.
+const announceCheckInterval = time.Hour
+
+// Posts rank of the previous month to announcement channels at the start
+// of every month in the given time zone.
+func (mod *ModSwears) announceRankMonthly(location *time.Location) {
+	for {
+		now := utils.TimeClock.Now().In(location)
+		mod.announceRank(now)
+		sleep := nextMonthStart(now).Sub(now)
+		if sleep > announceCheckInterval {
+			sleep = announceCheckInterval
+		}
+		time.Sleep(sleep)
+	}
+}
+
+func (mod *ModSwears) announceRank(now time.Time) {
+	month, year, marked, due := takeMonthToAnnounce(mod.state.Settings(), now)
+	if !marked {
+		return
+	}
+	// Announced month is saved first, so the rank is not posted again
+	// after a restart.
+	if mod.state.SaveSettings() != Success {
+		log.Println("ModSwears: cannot save announced month, rank not announced")
+		return
+	}
+	if !due {
+		return
+	}
+	channelIds := mod.state.Settings().GetChansWithSetting(SettingAnnounceRank)
+	if len(channelIds) == 0 {
+		return
+	}
+	query := monthQueryIn(month, year, now.Location())
+	rank := mod.getMonthRankByQuery(query, month, year, "")
+	for _, channelId := range channelIds {
+		mod.state.AsyncResponse(mods.Response{Message: rank, ChannelId: channelId})
+	}
+}
+
+// Marks the month before now as announced and returns it, unless it was
+// marked before. The first month marked is not due, so the rank is not
+// announced in the middle of a month the bot has been installed in.
+// Returns the month, whether it was marked and whether it is due.
+func takeMonthToAnnounce(s settings.Settings, now time.Time) (int, int, bool, bool) {
+	prevMonth := utils.LastDayOfPrevMonth(now)
+	month, year := int(prevMonth.Month()), prevMonth.Year()
+	monthKey := getMonthKey(month, year)
+	lastMonthKey, announced := s.GetSetting(SettingLastAnnouncedMonth)
+	if lastMonthKey == monthKey {
+		return 0, 0, false, false
+	}
+	s.SetSetting(SettingLastAnnouncedMonth, monthKey)
+	return month, year, true, announced
+}
+
+func nextMonthStart(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month()+1, 1, 0, 0, 0, 0, date.Location())
+}
+
+func (mod *ModSwears) setAnnounceRank(channelId string, on bool) string {
+	if on {
+		mod.state.Settings().SetChanSetting(channelId, SettingAnnounceRank, "on")
+	} else {
+		mod.state.Settings().RemoveChanSetting(channelId, SettingAnnounceRank)
+	}
+	err := mod.state.SaveSettings()
+	if err != Success {
+		return getErrMessage(err, mod.config)
+	}
+	if on {
+		return mod.config.OnAnnounceOnResponse
+	}
+	return mod.config.OnAnnounceOffResponse
+}
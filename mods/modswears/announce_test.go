@@ -0,0 +1,91 @@
+package modswears
+
+import (
+	"../../mods"
+	"../../settings"
+	"../../utils"
+	"github.com/nlopes/slack"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTakeMonthToAnnounce(t *testing.T) {
+	s := settings.NewSettings()
+	assertTakeMonthToAnnounce(t, s, utils.NewLocalDateTime(2016, 3, 15, 12, 0, 0), 2, 2016, true, false)
+	assertTakeMonthToAnnounce(t, s, utils.NewLocalDateTime(2016, 3, 31, 23, 59, 59), 0, 0, false, false)
+	assertTakeMonthToAnnounce(t, s, utils.NewLocalDateTime(2016, 4, 1, 0, 0, 0), 3, 2016, true, true)
+	assertTakeMonthToAnnounce(t, s, utils.NewLocalDateTime(2016, 4, 1, 0, 0, 1), 0, 0, false, false)
+	assertTakeMonthToAnnounce(t, s, utils.NewLocalDateTime(2017, 1, 3, 8, 0, 0), 12, 2016, true, true)
+}
+
+func TestAnnounceRank(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+	defer os.Remove(tmpFilePath + ".settings")
+	defer os.Remove(tmpFilePath + ".users")
+
+	mod := createStats(t, tmpFilePath)
+	responses := make(chan mods.Response, 1)
+	state := mods.NewState(nil, responses)
+	if !state.Init(tmpFilePath+".settings", tmpFilePath+".users") {
+		t.Fatal("Cannot init mod state")
+	}
+	mod.state = state
+	mod.config.RankLineFormat = "{index}. {user}: {count}"
+	mod.state.Users().SetUsers([]slack.User{
+		slack.User{ID: "user1", Name: "john"},
+		slack.User{ID: "user2", Name: "jane"},
+	})
+	mod.state.Settings().SetChanSetting("C1", SettingAnnounceRank, "on")
+	location := time.FixedZone("UTC+14", 14*60*60)
+	mod.AddSwears(time.Date(2016, 3, 31, 23, 0, 0, 0, location), "user1", "C1", "", swearMatches("a"))
+	mod.AddSwears(time.Date(2016, 4, 1, 1, 0, 0, 0, location), "user2", "C1", "", swearMatches("a"))
+
+	mod.announceRank(time.Date(2016, 3, 20, 12, 0, 0, 0, location))
+	assertLoadedSetting(t, tmpFilePath+".settings", SettingLastAnnouncedMonth, getMonthKey(2, 2016))
+	mod.announceRank(time.Date(2016, 4, 1, 2, 0, 0, 0, location))
+	assertLoadedSetting(t, tmpFilePath+".settings", SettingLastAnnouncedMonth, getMonthKey(3, 2016))
+	response := <-responses
+	expected := "*Monthly Rank* - March 2016\n1. john: 1\n"
+	if response.ChannelId != "C1" || response.Message != expected {
+		t.Fatalf("Expected rank '%s' in C1, got '%s' in %s", expected, response.Message, response.ChannelId)
+	}
+}
+
+func TestNextMonthStart(t *testing.T) {
+	location := time.FixedZone("UTC+2", 2*60*60)
+	date := time.Date(2016, 12, 31, 23, 0, 0, 0, location)
+	expected := time.Date(2017, 1, 1, 0, 0, 0, 0, location)
+	actual := nextMonthStart(date)
+	if !actual.Equal(expected) || actual.Location() != location {
+		t.Fatalf("Expected next month to start at %v, got %v", expected, actual)
+	}
+}
+
+func assertTakeMonthToAnnounce(
+	t *testing.T,
+	s settings.Settings,
+	now time.Time,
+	expectedMonth int,
+	expectedYear int,
+	expectedMarked bool,
+	expectedDue bool) {
+
+	month, year, marked, due := takeMonthToAnnounce(s, now)
+	if month != expectedMonth || year != expectedYear || marked != expectedMarked || due != expectedDue {
+		t.Fatalf("Expected month %d.%d marked %v due %v at %v, got %d.%d marked %v due %v",
+			expectedMonth, expectedYear, expectedMarked, expectedDue, now, month, year, marked, due)
+	}
+}
+
+func assertLoadedSetting(t *testing.T, fileName string, key string, expected string) {
+	s := settings.NewSettings()
+	if err := s.Load(fileName); err != Success {
+		t.Fatalf("Expected to load settings without errors, got %v", err)
+	}
+	actual, _ := s.GetSetting(key)
+	if actual != expected {
+		t.Fatalf("Expected saved setting %s '%s', got '%s'", key, expected, actual)
+	}
+}
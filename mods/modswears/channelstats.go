@@ -0,0 +1,97 @@
+package modswears
+
+import (
+	"sort"
+)
+
+type ChannelStats struct {
+	ChannelId  string
+	SwearCount int
+	Score      int
+}
+
+type ByChannelSwearCount []*ChannelStats
+
+func (a ByChannelSwearCount) Len() int {
+	return len(a)
+}
+
+func (a ByChannelSwearCount) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
+}
+
+// Channels with equal counts are ordered by score, then by id.
+func (a ByChannelSwearCount) Less(i, j int) bool {
+	if a[i].SwearCount != a[j].SwearCount {
+		return a[i].SwearCount > a[j].SwearCount
+	}
+	if a[i].Score != a[j].Score {
+		return a[i].Score > a[j].Score
+	}
+	return a[i].ChannelId < a[j].ChannelId
+}
+
+type ByChannelScore []*ChannelStats
+
+func (a ByChannelScore) Len() int {
+	return len(a)
+}
+
+func (a ByChannelScore) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
+}
+
+// Channels with equal scores are ordered by count, then by id.
+func (a ByChannelScore) Less(i, j int) bool {
+	if a[i].Score != a[j].Score {
+		return a[i].Score > a[j].Score
+	}
+	if a[i].SwearCount != a[j].SwearCount {
+		return a[i].SwearCount > a[j].SwearCount
+	}
+	return a[i].ChannelId < a[j].ChannelId
+}
+
+// GetChannelRank sums events matching the query per channel and sorts
+// channels as set in config. Events without a channel, imported from
+// monthly stats, are left out.
+func (mod *ModSwears) GetChannelRank(query StatsQuery) ([]*ChannelStats, int) {
+	events, err := mod.stats.Query(query)
+	if err != Success {
+		return nil, err
+	}
+	rank := channelStatsOf(events)
+	if mod.config.RankSortBy == RankSortByCount {
+		sort.Stable(ByChannelSwearCount(rank))
+	} else {
+		sort.Stable(ByChannelScore(rank))
+	}
+	return rank, Success
+}
+
+// Sums events per channel in order of first use. Channels whose swears
+// have all been reversed are left out.
+func channelStatsOf(events []SwearEvent) []*ChannelStats {
+	channelIdToStats := make(map[string]*ChannelStats)
+	channelStats := []*ChannelStats{}
+	for _, event := range events {
+		if event.ChannelId == "" {
+			continue
+		}
+		channel := channelIdToStats[event.ChannelId]
+		if channel == nil {
+			channel = &ChannelStats{ChannelId: event.ChannelId}
+			channelIdToStats[event.ChannelId] = channel
+			channelStats = append(channelStats, channel)
+		}
+		channel.SwearCount += event.Count
+		channel.Score += event.Score
+	}
+	counted := channelStats[:0]
+	for _, channel := range channelStats {
+		if channel.SwearCount != 0 {
+			counted = append(counted, channel)
+		}
+	}
+	return counted
+}
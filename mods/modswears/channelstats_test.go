@@ -0,0 +1,75 @@
+package modswears
+
+import (
+	"../../utils"
+	"reflect"
+	"testing"
+)
+
+func TestChannelRank(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+
+	mod := createStats(t, tmpFilePath)
+	mod.config.RankSortBy = RankSortByScore
+	mod.getRules().ruleInfos.Rules["s"] = &RuleInfo{Category: "slur"}
+	assertAddSwearCount(t, mod, 1, 2016, "user1", 10)
+	now := utils.NewLocalDate(2016, 1, 2)
+	mod.AddSwears(now, "user1", "channel1", "", []SwearMatch{SwearMatch{Text: "v", Rule: "v"}})
+	mod.AddSwears(now, "user2", "channel1", "", []SwearMatch{
+		SwearMatch{Text: "v", Rule: "v"},
+		SwearMatch{Text: "v", Rule: "v"},
+	})
+	mod.AddSwears(now, "user1", "channel2", "", []SwearMatch{
+		SwearMatch{Text: "s", Rule: "s"},
+		SwearMatch{Text: "s", Rule: "s"},
+	})
+
+	expected := []*ChannelStats{
+		&ChannelStats{ChannelId: "channel2", SwearCount: 2, Score: 10},
+		&ChannelStats{ChannelId: "channel1", SwearCount: 3, Score: 6},
+	}
+	assertChannelRank(t, mod, StatsQuery{}, expected)
+
+	mod.config.RankSortBy = RankSortByCount
+	expected[0], expected[1] = expected[1], expected[0]
+	assertChannelRank(t, mod, monthQuery(1, 2016), expected)
+	assertChannelRank(t, mod, monthQuery(2, 2016), []*ChannelStats{})
+
+	query := monthQuery(1, 2016)
+	query.ChannelId = "channel1"
+	assertStatsQuery(t, mod, query, []*UserStats{
+		&UserStats{UserId: "user2", SwearCount: 2, Score: 4, Categories: map[string]int{"vulgar": 2}},
+		&UserStats{UserId: "user1", SwearCount: 1, Score: 2, Categories: map[string]int{"vulgar": 1}},
+	})
+}
+
+func TestFormatChannelRank(t *testing.T) {
+	config := NewModSwearsConfig()
+	config.ChannelRankHeaderFormat = "Channels"
+	config.ChannelRankLineFormat = "{index}. {channel}: {count}/{score}"
+	channelStats := []*ChannelStats{
+		&ChannelStats{ChannelId: "C1", SwearCount: 2, Score: 4},
+		&ChannelStats{ChannelId: "C2", SwearCount: 1, Score: 5},
+	}
+	expected := "Channels\n1. <#C1>: 2/4\n2. <#C2>: 1/5\n"
+	actual := formatChannelRank(config, channelStats)
+	if actual != expected {
+		t.Fatalf("Expected channel rank '%s', got '%s'", expected, actual)
+	}
+
+	header := formatMonthlyRankHeader(config.MonthlyChannelRankHeaderFormat, config.MonthNames, 3, 2016, "<#C1>")
+	if header != "*Monthly Rank* - March 2016 in <#C1>" {
+		t.Fatalf("Unexpected monthly channel rank header '%s'", header)
+	}
+}
+
+func assertChannelRank(t *testing.T, mod *ModSwears, query StatsQuery, expected []*ChannelStats) {
+	actual, err := mod.GetChannelRank(query)
+	if err != Success {
+		t.Fatalf("Expected no error when getting channel rank but got %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected channel rank %v, got %v", expected, actual)
+	}
+}
@@ -2,31 +2,156 @@ package modswears
 
 type ModSwearsConfig struct {
 	AddRuleRegex        string
+	AddExceptionRegex   string
+	RemoveRuleRegex     string
+	ListRulesRegex      string
+	WhichRuleRegex      string
+	ReloadRulesRegex    string
 	CurrMonthRankRegex  string
 	PrevMonthRankRegex  string
 	TotalRankRegex      string
+	ChannelRankRegex    string
+	WeekRankRegex       string
+	YearRankRegex       string
+	MonthRankRegex      string
+	DateRangeRankRegex  string
+	TopSwearsRegex      string
+	FavSwearsRegex      string
+	MyStatsRegex        string
+	UserStatsRegex      string
+	MigrateStatsRegex   string
 	SwearNotifyOnRegex  string
 	SwearNotifyOffRegex string
+	AnnounceOnRegex     string
+	AnnounceOffRegex    string
+	MyDebtRegex         string
+	JarTotalRegex       string
+	PaidRegex           string
+	PardonRegex         string
+	ResetStatsRegex     string
+	MergeUserRegex      string
+	CorrectionsRegex    string
 
 	SwearFormat              string
 	OnSwearsFoundResponse    string
 	OnAddRuleResponse        string
+	OnAddExceptionResponse   string
+	OnRemoveRuleResponse     string
+	RuleFormat               string
+	LemmaRuleFormat          string
+	OnListRulesResponse      string
+	OnNoRulesResponse        string
+	OnRuleMatchResponse      string
+	OnNoRuleMatchResponse    string
+	OnReloadRulesResponse    string
+	OnMigrateStatsResponse   string
 	OnEmptyRankResponse      string
 	OnSwearNotifyOnResponse  string
 	OnSwearNotifyOffResponse string
+	OnAnnounceOnResponse     string
+	OnAnnounceOffResponse    string
 	MonthlyRankHeaderFormat  string
 	TotalRankHeaderFormat    string
+	WeeklyRankHeaderFormat   string
+	YearlyRankHeaderFormat   string
+	PeriodRankHeaderFormat   string
+	DateFormat               string
 	RankLineFormat           string
+	ChannelFormat            string
 	MonthNames               []string
 
-	OnUserFetchErr       string
-	OnDictFileReadErr    string
-	OnAddRuleConflictErr string
-	OnAddRuleSaveErr     string
-	OnInvalidWildcardErr string
+	MonthlyChannelRankHeaderFormat string
+	TotalChannelRankHeaderFormat   string
+	ChannelRankHeaderFormat        string
+	ChannelRankLineFormat          string
 
-	OnStatsFileReadErr string
-	OnStatsSaveErr     string
+	TopSwearsHeaderFormat            string
+	MonthlyTopSwearsHeaderFormat     string
+	UserTopSwearsHeaderFormat        string
+	MonthlyUserTopSwearsHeaderFormat string
+	TopSwearLineFormat               string
+	TopSwearsLimit                   int
+
+	UserReportFormat      string
+	OnNoUserStatsResponse string
+	RankPositionFormat    string
+	NotRankedFormat       string
+	StatsMonthFormat      string
+	TrendUpFormat         string
+	TrendDownFormat       string
+	TrendSameFormat       string
+
+	OnMyDebtResponse   string
+	OnJarTotalResponse string
+	OnPaidResponse     string
+	JarAmountFormat    string
+	JarSwearPrice      int
+	JarCategoryPrices  map[string]int
+
+	OnPardonResponse        string
+	OnResetStatsResponse    string
+	OnMergeUserResponse     string
+	OnNoCorrectionsResponse string
+	CorrectionsHeaderFormat string
+	PardonCorrectionFormat  string
+	ResetCorrectionFormat   string
+	MergeCorrectionFormat   string
+
+	DefaultRuleCategory string
+	CategoryWeights     map[string]int
+	RankSortBy          string
+	UserNameSource      string
+
+	FoldDiacritics        bool
+	CollapseRepeatedChars bool
+	RemoveSeparators      bool
+	SeparatorChars        string
+	ExpandMaskChars       bool
+	MaskChars             string
+	ReplaceLookalikes     bool
+	LookalikeChars        map[string]string
+	RegexRuleMaxSize      int
+	RegexRuleMinLetters   int
+	WatchDictFile         bool
+	WatchDictIntervalSec  int
+	StatsFlushIntervalSec int
+	UserFetchRetrySec     int
+	StatsCompactLogSize   int
+	StatsBackend          string
+	AnnounceTimeZone      string
+	ReverseDeletedSwears  bool
+	SkipInlineCode        bool
+	SkipCodeBlocks        bool
+	SkipQuotes            bool
+	SkipLinks             bool
+
+	OnUserFetchErr           string
+	OnDictFileReadErr        string
+	OnAddRuleConflictErr     string
+	OnAddRuleSaveErr         string
+	OnInvalidWildcardErr     string
+	OnRuleNotFoundErr        string
+	OnExceptionConflictErr   string
+	OnInvalidRegexRuleErr    string
+	OnRegexRuleTooComplexErr string
+	OnRegexRuleTooBroadErr   string
+	OnUnknownLemmaErr        string
+	OnLemmaFormRemoveErr     string
+	OnRuleInfoFileReadErr    string
+
+	OnStatsFileReadErr  string
+	OnStatsSaveErr      string
+	OnStatsMigrationErr string
+	OnInvalidPeriodErr  string
+
+	OnJarFileReadErr       string
+	OnJarSaveErr           string
+	OnNotAdminErr          string
+	OnInvalidJarPaymentErr string
+
+	OnCorrectionsFileReadErr string
+	OnCorrectionsSaveErr     string
+	OnNoSwearsToCorrectErr   string
 
 	OnSettingsFileReadErr string
 	OnSettingsSaveErr     string
@@ -34,32 +159,175 @@ type ModSwearsConfig struct {
 
 func NewModSwearsConfig() *ModSwearsConfig {
 	return &ModSwearsConfig{
-		AddRuleRegex:        "(?i)^\\s*add rule:\\s*([a-z0-9*]+)\\s*$",
-		CurrMonthRankRegex:  "(?i)^\\s*curr\\s+rank\\s*$",
-		PrevMonthRankRegex:  "(?i)^\\s*prev\\s+rank\\s*$",
-		TotalRankRegex:      "(?i)^\\s*total\\s+rank\\s*$",
+		AddRuleRegex:        "(?i)^\\s*add rule:\\s*(/.+/|\\+\\pL+|~?[\\pL0-9*]+(?:\\s+[\\pL0-9*]+)*)\\s*$",
+		AddExceptionRegex:   "(?i)^\\s*add exception:\\s*([\\pL0-9*]+)\\s*$",
+		RemoveRuleRegex:     "(?i)^\\s*remove rule:\\s*([^\\s]+(?:\\s+[^\\s]+)*)\\s*$",
+		ListRulesRegex:      "(?i)^\\s*list\\s+rules(?:\\s+([^\\s]+))?\\s*$",
+		WhichRuleRegex:      "(?i)^\\s*which\\s+rule\\s+matches\\s+([^\\s]+(?:\\s+[^\\s]+)*)\\s*$",
+		ReloadRulesRegex:    "(?i)^\\s*reload\\s+rules\\s*$",
+		CurrMonthRankRegex:  "(?i)^\\s*curr\\s+rank(?:\\s+(?:(here)|in\\s+<#(\\w+)(?:\\|[^>]*)?>))?\\s*$",
+		PrevMonthRankRegex:  "(?i)^\\s*prev\\s+rank(?:\\s+(?:(here)|in\\s+<#(\\w+)(?:\\|[^>]*)?>))?\\s*$",
+		TotalRankRegex:      "(?i)^\\s*total\\s+rank(?:\\s+(?:(here)|in\\s+<#(\\w+)(?:\\|[^>]*)?>))?\\s*$",
+		ChannelRankRegex:    "(?i)^\\s*channel\\s+rank\\s*$",
+		WeekRankRegex:       "(?i)^\\s*week\\s+rank\\s*$",
+		YearRankRegex:       "(?i)^\\s*year\\s+rank(?:\\s+(\\d{4}))?\\s*$",
+		MonthRankRegex:      "(?i)^\\s*rank\\s+(\\pL+|\\d{1,2})\\s+(\\d{4})\\s*$",
+		DateRangeRankRegex:  "(?i)^\\s*rank\\s+(\\d{4}-\\d{2}-\\d{2})\\s*\\.\\.\\s*(\\d{4}-\\d{2}-\\d{2})\\s*$",
+		TopSwearsRegex:      "(?i)^\\s*(?:(curr|prev|total|(?:\\pL+|\\d{1,2})\\s+\\d{4})\\s+)?top\\s+swears(?:\\s+<@(\\w+)(?:\\|[^>]*)?>)?\\s*$",
+		FavSwearsRegex:      "(?i)^\\s*my\\s+favou?rite\\s+swears\\s*$",
+		MyStatsRegex:        "(?i)^\\s*my\\s+stats\\s*$",
+		UserStatsRegex:      "(?i)^\\s*stats\\s+<@(\\w+)(?:\\|[^>]*)?>\\s*$",
+		MigrateStatsRegex:   "(?i)^\\s*migrate\\s+stats\\s*$",
 		SwearNotifyOnRegex:  "(?i)^\\s*notify\\s+on\\s*$",
 		SwearNotifyOffRegex: "(?i)^\\s*notify\\s+off\\s*$",
+		AnnounceOnRegex:     "(?i)^\\s*announce\\s+rank\\s+on\\s*$",
+		AnnounceOffRegex:    "(?i)^\\s*announce\\s+rank\\s+off\\s*$",
+		MyDebtRegex:         "(?i)^\\s*my\\s+debt\\s*$",
+		JarTotalRegex:       "(?i)^\\s*jar\\s+total\\s*$",
+		PaidRegex:           "(?i)^\\s*paid\\s+<@(\\w+)(?:\\|[^>]*)?>\\s+(\\d{1,9})\\s*$",
+		PardonRegex:         "(?i)^\\s*pardon\\s+<@(\\w+)(?:\\|[^>]*)?>\\s+(\\d{1,9})(?:\\s+(\\pL+|\\d{1,2})\\s+(\\d{4}))?\\s*$",
+		ResetStatsRegex:     "(?i)^\\s*reset\\s+stats\\s+<@(\\w+)(?:\\|[^>]*)?>\\s*$",
+		MergeUserRegex:      "(?i)^\\s*merge\\s+user\\s+<@(\\w+)(?:\\|[^>]*)?>\\s+<@(\\w+)(?:\\|[^>]*)?>\\s*$",
+		CorrectionsRegex:    "(?i)^\\s*corrections\\s+<@(\\w+)(?:\\|[^>]*)?>\\s*$",
 
 		SwearFormat:              "{index}. *{swear}*",
 		OnAddRuleResponse:        "Rule '{rule}' added.",
+		OnAddExceptionResponse:   "Exception '{rule}' added.",
+		OnRemoveRuleResponse:     "Rule '{rule}' removed.",
+		RuleFormat:               "`{rule}`",
+		LemmaRuleFormat:          "`{rule}` ({forms})",
+		OnListRulesResponse:      "{count} rules: {rules}",
+		OnNoRulesResponse:        "No rules found.",
+		OnRuleMatchResponse:      "'{word}' is matched by rule '{rule}'.",
+		OnNoRuleMatchResponse:    "'{word}' is not matched by any rule.",
+		OnReloadRulesResponse:    "Rules reloaded, {count} rules loaded.",
+		OnMigrateStatsResponse:   "Stats migrated, {count} events copied.",
 		OnSwearsFoundResponse:    "{count} swears found: {swears}",
 		OnEmptyRankResponse:      "Rank is empty.",
 		OnSwearNotifyOnResponse:  "Swear notification is on",
 		OnSwearNotifyOffResponse: "Swear notification is off",
+		OnAnnounceOnResponse:     "Monthly rank will be announced here",
+		OnAnnounceOffResponse:    "Monthly rank will not be announced here",
 		MonthlyRankHeaderFormat:  "*Monthly Rank* - {month} {year}",
 		TotalRankHeaderFormat:    "*Total Rank*",
+		WeeklyRankHeaderFormat:   "*Weekly Rank* - {from} - {to}",
+		YearlyRankHeaderFormat:   "*Yearly Rank* - {year}",
+		PeriodRankHeaderFormat:   "*Rank* - {from} - {to}",
+		DateFormat:               "2006-01-02",
 		RankLineFormat:           "{index}. *{user}*: {count} swears",
+		ChannelFormat:            "<#{channel}>",
 		MonthNames:               []string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
 
-		OnUserFetchErr:        "Error when fetching slack users!",
-		OnDictFileReadErr:     "Error when reading database!",
-		OnAddRuleConflictErr:  "Similar rule already exists!",
-		OnAddRuleSaveErr:      "Error when saving to database!",
-		OnInvalidWildcardErr:  "Invalid wildcard placement!",
-		OnStatsFileReadErr:    "Error when reading stats file!",
-		OnStatsSaveErr:        "Error when saving to stats file!",
-		OnSettingsFileReadErr: "Error when reading settings file!",
-		OnSettingsSaveErr:     "Error when saving to settings file!",
+		MonthlyChannelRankHeaderFormat: "*Monthly Rank* - {month} {year} in {channel}",
+		TotalChannelRankHeaderFormat:   "*Total Rank* in {channel}",
+		ChannelRankHeaderFormat:        "*Channel Rank*",
+		ChannelRankLineFormat:          "{index}. {channel}: {count} swears, {score} points",
+
+		TopSwearsHeaderFormat:            "*Top Swears*",
+		MonthlyTopSwearsHeaderFormat:     "*Top Swears* - {month} {year}",
+		UserTopSwearsHeaderFormat:        "*Favourite Swears of {user}*",
+		MonthlyUserTopSwearsHeaderFormat: "*Favourite Swears of {user}* - {month} {year}",
+		TopSwearLineFormat:               "{index}. *{swear}*: {count} times",
+		TopSwearsLimit:                   10,
+
+		UserReportFormat: "*Stats of {user}*\n" +
+			"This month: {monthcount} swears, {monthscore} points, {monthposition}\n" +
+			"All time: {totalcount} swears, {totalscore} points, {totalposition}\n" +
+			"Best month: {bestmonth} ({bestcount} swears)\n" +
+			"Worst month: {worstmonth} ({worstcount} swears)\n" +
+			"Trend: {trend}\n" +
+			"Swear-free streak: {streak} days",
+		OnNoUserStatsResponse: "No swears of {user} yet.",
+		RankPositionFormat:    "#{position} of {users}",
+		NotRankedFormat:       "not ranked",
+		StatsMonthFormat:      "{month} {year}",
+		TrendUpFormat:         "{diff} more than last month",
+		TrendDownFormat:       "{diff} fewer than last month",
+		TrendSameFormat:       "same as last month",
+
+		OnMyDebtResponse:   "Your debt: {debt} (fined {fines}, paid {paid})",
+		OnJarTotalResponse: "Swear jar: {paid} paid, {debt} still owed, {fines} fined in total",
+		OnPaidResponse:     "Payment of {amount} from {user} recorded, debt left: {debt}",
+		JarAmountFormat:    "${amount}",
+		JarSwearPrice:      1,
+		JarCategoryPrices: map[string]int{
+			"mild":   1,
+			"vulgar": 2,
+			"slur":   5,
+		},
+
+		OnPardonResponse:        "{count} swears of {user} pardoned",
+		OnResetStatsResponse:    "Stats of {user} reset, {count} swears taken back",
+		OnMergeUserResponse:     "{count} swears of {user} moved to {target}",
+		OnNoCorrectionsResponse: "No corrections of {user}",
+		CorrectionsHeaderFormat: "*Corrections of {user}*",
+		PardonCorrectionFormat:  "{time} {admin} pardoned {count} swears of {user} in {month}",
+		ResetCorrectionFormat:   "{time} {admin} reset stats of {user}, {count} swears taken back",
+		MergeCorrectionFormat:   "{time} {admin} moved {count} swears of {user} to {target}",
+
+		DefaultRuleCategory: CategoryVulgar,
+		CategoryWeights: map[string]int{
+			CategoryMild:   1,
+			CategoryVulgar: 2,
+			CategorySlur:   5,
+		},
+		RankSortBy:     RankSortByCount,
+		UserNameSource: UserNameLogin,
+
+		FoldDiacritics:        true,
+		CollapseRepeatedChars: true,
+		RemoveSeparators:      true,
+		SeparatorChars:        ".,-_+~|'`\"*#",
+		ExpandMaskChars:       true,
+		MaskChars:             "*#",
+		ReplaceLookalikes:     true,
+		LookalikeChars: map[string]string{
+			"0": "o", "1": "i", "3": "e", "4": "a", "5": "s", "7": "t", "8": "b",
+			"@": "a", "$": "s", "€": "e",
+			"а": "a", "в": "b", "е": "e", "к": "k", "м": "m", "н": "h", "о": "o",
+			"р": "p", "с": "c", "т": "t", "у": "y", "х": "x", "і": "i", "ј": "j",
+			"ѕ": "s", "ԁ": "d", "ԛ": "q", "ԝ": "w", "ү": "y",
+		},
+		RegexRuleMaxSize:      500,
+		RegexRuleMinLetters:   3,
+		WatchDictFile:         true,
+		WatchDictIntervalSec:  10,
+		StatsFlushIntervalSec: 300,
+		UserFetchRetrySec:     3600,
+		StatsCompactLogSize:   1000,
+		StatsBackend:          StatsBackendJson,
+		AnnounceTimeZone:      "Local",
+		ReverseDeletedSwears:  true,
+		SkipInlineCode:        true,
+		SkipCodeBlocks:        true,
+		SkipQuotes:            true,
+		SkipLinks:             true,
+
+		OnUserFetchErr:           "Error when fetching slack users!",
+		OnDictFileReadErr:        "Error when reading database!",
+		OnAddRuleConflictErr:     "Similar rule already exists!",
+		OnAddRuleSaveErr:         "Error when saving to database!",
+		OnInvalidWildcardErr:     "Invalid wildcard placement!",
+		OnRuleNotFoundErr:        "Rule not found!",
+		OnExceptionConflictErr:   "Exception would override a whole rule!",
+		OnInvalidRegexRuleErr:    "Invalid regular expression!",
+		OnRegexRuleTooComplexErr: "Regular expression is too complex!",
+		OnRegexRuleTooBroadErr:   "Regular expression is too broad!",
+		OnUnknownLemmaErr:        "Cannot inflect this word!",
+		OnLemmaFormRemoveErr:     "This word is a form of rule '{rule}', remove the whole rule instead!",
+		OnRuleInfoFileReadErr:    "Error when reading rule descriptions!",
+		OnStatsFileReadErr:       "Error when reading stats file!",
+		OnStatsSaveErr:           "Error when saving to stats file!",
+		OnStatsMigrationErr:      "Stats can only be migrated into an empty key-value store!",
+		OnInvalidPeriodErr:       "Invalid period!",
+		OnJarFileReadErr:         "Error when reading swear jar!",
+		OnJarSaveErr:             "Error when saving to swear jar!",
+		OnNotAdminErr:            "Only admins can do that!",
+		OnInvalidJarPaymentErr:   "Payment must be greater than zero!",
+		OnCorrectionsFileReadErr: "Error when reading corrections!",
+		OnCorrectionsSaveErr:     "Error when saving corrections!",
+		OnNoSwearsToCorrectErr:   "No swears to correct!",
+		OnSettingsFileReadErr:    "Error when reading settings file!",
+		OnSettingsSaveErr:        "Error when saving to settings file!",
 	}
 }
@@ -2,6 +2,7 @@ package modswears
 
 import (
 	"../../utils"
+	"regexp"
 	"strings"
 	"testing"
 )
@@ -13,3 +14,154 @@ func TestConfigIntegrity(t *testing.T) {
 		t.Fatalf("Found empty fields in config: %s", strings.Join(emptyFields, ", "))
 	}
 }
+
+func TestAddRuleRegex(t *testing.T) {
+	config := NewModSwearsConfig()
+	regex := regexp.MustCompile(config.AddRuleRegex)
+	assertRegexGroup(t, regex, "add rule: abc*", "abc*")
+	assertRegexGroup(t, regex, " add rule:~kurw ", "~kurw")
+	assertRegexGroup(t, regex, "add rule: ja  pierdolę", "ja  pierdolę")
+	assertRegexGroup(t, regex, "add rule: do dup*", "do dup*")
+	assertRegexGroup(t, regex, "add rule: /p[ie]+rd.*/", "/p[ie]+rd.*/")
+	assertRegexGroup(t, regex, "add rule: +dupa", "+dupa")
+	assertRegexGroup(t, regex, "add rule: +dup*", "")
+	assertRegexGroup(t, regex, "add rule: a-b", "")
+}
+
+func TestRemoveRuleRegex(t *testing.T) {
+	config := NewModSwearsConfig()
+	regex := regexp.MustCompile(config.RemoveRuleRegex)
+	assertRegexGroup(t, regex, "remove rule: abc*", "abc*")
+	assertRegexGroup(t, regex, " remove rule:~kurw ", "~kurw")
+	assertRegexGroup(t, regex, "remove rule: ja  pierdolę ", "ja  pierdolę")
+	assertRegexGroup(t, regex, "remove rule: /p[ie]+rd .*/", "/p[ie]+rd .*/")
+}
+
+func TestWhichRuleRegex(t *testing.T) {
+	config := NewModSwearsConfig()
+	regex := regexp.MustCompile(config.WhichRuleRegex)
+	assertRegexGroup(t, regex, "which rule matches kurwa", "kurwa")
+	assertRegexGroup(t, regex, "which rule matches ja  pierdolę ", "ja  pierdolę")
+}
+
+func TestRankRegex(t *testing.T) {
+	config := NewModSwearsConfig()
+	regex := regexp.MustCompile(config.CurrMonthRankRegex)
+	assertRankChannel(t, regex, "curr rank", "")
+	assertRankChannel(t, regex, " curr rank here ", "C1")
+	assertRankChannel(t, regex, "curr rank in <#C2>", "C2")
+	assertRankChannel(t, regex, "curr rank in <#C2|general>", "C2")
+	if regex.MatchString("curr rank in general") {
+		t.Fatal("Expected rank regex to match only channel links")
+	}
+	regex = regexp.MustCompile(config.PrevMonthRankRegex)
+	assertRankChannel(t, regex, "prev rank in <#C2|general>", "C2")
+	regex = regexp.MustCompile(config.TotalRankRegex)
+	assertRankChannel(t, regex, "total rank here", "C1")
+}
+
+func TestTopSwearsRegex(t *testing.T) {
+	config := NewModSwearsConfig()
+	regex := regexp.MustCompile(config.TopSwearsRegex)
+	assertRegexGroup(t, regex, "top swears", "")
+	assertRegexGroup(t, regex, "prev top swears", "prev")
+	assertRegexGroup(t, regex, "March 2016 top swears", "March 2016")
+	assertRegexGroup(t, regex, "3  2016 top swears", "3  2016")
+	groups := regex.FindStringSubmatch("curr top swears <@U1|john>")
+	if groups == nil || groups[1] != "curr" || groups[2] != "U1" {
+		t.Fatalf("Expected month and user to be captured, got %v", groups)
+	}
+	regex = regexp.MustCompile(config.FavSwearsRegex)
+	if !regex.MatchString("my favorite swears") || !regex.MatchString("My favourite swears") {
+		t.Fatal("Expected favourite swears regex to match both spellings")
+	}
+}
+
+func TestPeriodRankRegex(t *testing.T) {
+	config := NewModSwearsConfig()
+	regex := regexp.MustCompile(config.YearRankRegex)
+	assertRegexGroup(t, regex, "year rank", "")
+	assertRegexGroup(t, regex, "year rank 2016", "2016")
+	regex = regexp.MustCompile(config.MonthRankRegex)
+	assertRegexGroup(t, regex, "rank March 2016", "March")
+	assertRegexGroup(t, regex, "rank październik 2016", "październik")
+	assertRegexGroup(t, regex, "rank 3 2016", "3")
+	regex = regexp.MustCompile(config.DateRangeRankRegex)
+	assertRegexGroup(t, regex, "rank 2016-03-01..2016-05-31", "2016-03-01")
+	assertRegexGroup(t, regex, "rank 2016-03-01 .. 2016-05-31", "2016-03-01")
+	assertRegexGroup(t, regex, "rank 2016-03-01", "")
+}
+
+func TestUserStatsRegex(t *testing.T) {
+	config := NewModSwearsConfig()
+	regex := regexp.MustCompile(config.MyStatsRegex)
+	if !regex.MatchString(" My stats ") || regex.MatchString("my stats please") {
+		t.Fatal("Expected my stats regex to match only the whole command")
+	}
+	regex = regexp.MustCompile(config.UserStatsRegex)
+	assertRegexGroup(t, regex, "stats <@U1>", "U1")
+	assertRegexGroup(t, regex, "stats <@U1|john>", "U1")
+}
+
+func TestAnnounceRegex(t *testing.T) {
+	config := NewModSwearsConfig()
+	regex := regexp.MustCompile(config.AnnounceOnRegex)
+	if !regex.MatchString("Announce rank on") || regex.MatchString("announce rank off") {
+		t.Fatal("Expected announce on regex to match only turning announcements on")
+	}
+	regex = regexp.MustCompile(config.AnnounceOffRegex)
+	if !regex.MatchString(" announce  rank off ") || regex.MatchString("announce rank on") {
+		t.Fatal("Expected announce off regex to match only turning announcements off")
+	}
+}
+
+func TestPaidRegex(t *testing.T) {
+	config := NewModSwearsConfig()
+	regex := regexp.MustCompile(config.PaidRegex)
+	groups := regex.FindStringSubmatch("paid <@U1|john> 20")
+	if groups == nil || groups[1] != "U1" || groups[2] != "20" {
+		t.Fatalf("Expected user and amount to be captured, got %v", groups)
+	}
+	if regex.MatchString("paid <@U1> -20") || regex.MatchString("paid <@U1>") {
+		t.Fatal("Expected paid regex to require a positive amount")
+	}
+}
+
+func TestCorrectionRegex(t *testing.T) {
+	config := NewModSwearsConfig()
+	regex := regexp.MustCompile(config.PardonRegex)
+	groups := regex.FindStringSubmatch("pardon <@U1|john> 2 March 2016")
+	if groups == nil || groups[1] != "U1" || groups[2] != "2" || groups[3] != "March" || groups[4] != "2016" {
+		t.Fatalf("Expected user, count and month to be captured, got %v", groups)
+	}
+	assertRegexGroup(t, regex, "pardon <@U1> 2", "U1")
+	regex = regexp.MustCompile(config.MergeUserRegex)
+	groups = regex.FindStringSubmatch("merge user <@U1|john> <@U2>")
+	if groups == nil || groups[1] != "U1" || groups[2] != "U2" {
+		t.Fatalf("Expected both users to be captured, got %v", groups)
+	}
+	assertRegexGroup(t, regexp.MustCompile(config.ResetStatsRegex), "reset stats <@U1>", "U1")
+	assertRegexGroup(t, regexp.MustCompile(config.CorrectionsRegex), "corrections <@U1|john>", "U1")
+}
+
+func assertRankChannel(t *testing.T, regex *regexp.Regexp, message string, expected string) {
+	groups := regex.FindStringSubmatch(message)
+	if groups == nil {
+		t.Fatalf("Expected '%s' to match rank regex", message)
+	}
+	actual := getRankChannelId(groups, "C1")
+	if actual != expected {
+		t.Fatalf("Expected rank of channel '%s' for '%s', got '%s'", expected, message, actual)
+	}
+}
+
+func assertRegexGroup(t *testing.T, regex *regexp.Regexp, message string, expected string) {
+	actual := ""
+	groups := regex.FindStringSubmatch(message)
+	if groups != nil {
+		actual = groups[1]
+	}
+	if actual != expected {
+		t.Fatalf("Expected '%s' to be captured from '%s', got '%s'", expected, message, actual)
+	}
+}
@@ -0,0 +1,264 @@
+package modswears
+
+import (
+	"../../utils"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	CorrectionsFileReadErr = 81
+	CorrectionsSaveErr     = 82
+	NoSwearsToCorrectErr   = 83
+)
+
+const (
+	CorrectionPardon = "pardon"
+	CorrectionReset  = "reset"
+	CorrectionMerge  = "merge"
+)
+
+// Audit trail of corrections of stats made by admins.
+type StatsCorrections struct {
+	mutex       sync.Mutex
+	Corrections []*StatsCorrection
+}
+
+// Correction of swears of a user. Month is set for pardons only, target
+// user for merges only.
+type StatsCorrection struct {
+	Kind         string
+	UserId       string
+	TargetUserId string `json:",omitempty"`
+	Month        int    `json:",omitempty"`
+	Year         int    `json:",omitempty"`
+	Count        int
+	AdminId      string
+	Time         time.Time
+}
+
+// Swears of the same kind: events which differ only in count and score.
+type swearKey struct {
+	channelId string
+	rule      string
+	text      string
+	messageTs string
+	category  string
+	time      int64
+}
+
+func newStatsCorrections() *StatsCorrections {
+	return &StatsCorrections{
+		Corrections: []*StatsCorrection{},
+	}
+}
+
+func (mod *ModSwears) LoadCorrections() int {
+	corrections := newStatsCorrections()
+	err := utils.JsonFromFileCreate(mod.correctionsFileName, corrections)
+	if err != nil {
+		log.Printf("ModSwears: Cannot read corrections from file '%s'\n", mod.correctionsFileName)
+		return CorrectionsFileReadErr
+	}
+	mod.corrections = corrections
+	return Success
+}
+
+// PardonSwears takes back at most count swears of the user in the month,
+// the ones found first. Returns number of pardoned swears.
+func (mod *ModSwears) PardonSwears(
+	userId string,
+	count int,
+	month int,
+	year int,
+	adminId string,
+	now time.Time) (int, int) {
+
+	if count <= 0 {
+		return 0, NoSwearsToCorrectErr
+	}
+	query := monthQuery(month, year)
+	query.UserId = userId
+	swears, err := mod.remainingSwears(query)
+	if err != Success {
+		return 0, err
+	}
+	pardoned := 0
+	events := []SwearEvent{}
+	for _, swear := range swears {
+		if pardoned == count {
+			break
+		}
+		if swear.Count > count-pardoned {
+			swear.Score = swear.Score * (count - pardoned) / swear.Count
+			swear.Count = count - pardoned
+		}
+		pardoned += swear.Count
+		events = append(events, reversalOf(swear))
+	}
+	if len(events) == 0 {
+		return 0, NoSwearsToCorrectErr
+	}
+	return pardoned, mod.addCorrection(events, &StatsCorrection{
+		Kind:    CorrectionPardon,
+		UserId:  userId,
+		Month:   month,
+		Year:    year,
+		Count:   pardoned,
+		AdminId: adminId,
+		Time:    now,
+	})
+}
+
+// ResetStats takes back all swears of the user. Returns number of taken
+// back swears.
+func (mod *ModSwears) ResetStats(userId string, adminId string, now time.Time) (int, int) {
+	swears, err := mod.remainingSwears(StatsQuery{UserId: userId})
+	if err != Success {
+		return 0, err
+	}
+	if len(swears) == 0 {
+		return 0, NoSwearsToCorrectErr
+	}
+	count := 0
+	events := []SwearEvent{}
+	for _, swear := range swears {
+		count += swear.Count
+		events = append(events, reversalOf(swear))
+	}
+	return count, mod.addCorrection(events, &StatsCorrection{
+		Kind:    CorrectionReset,
+		UserId:  userId,
+		Count:   count,
+		AdminId: adminId,
+		Time:    now,
+	})
+}
+
+// MergeUser moves all swears of one user to another one, keeping their
+// time, channel and rule. Returns number of moved swears.
+func (mod *ModSwears) MergeUser(userId string, targetUserId string, adminId string, now time.Time) (int, int) {
+	swears, err := mod.remainingSwears(StatsQuery{UserId: userId})
+	if err != Success {
+		return 0, err
+	}
+	if len(swears) == 0 || userId == targetUserId {
+		return 0, NoSwearsToCorrectErr
+	}
+	count := 0
+	events := []SwearEvent{}
+	for _, swear := range swears {
+		count += swear.Count
+		moved := swear
+		moved.UserId = targetUserId
+		moved.Correction = true
+		events = append(events, reversalOf(swear), moved)
+	}
+	return count, mod.addCorrection(events, &StatsCorrection{
+		Kind:         CorrectionMerge,
+		UserId:       userId,
+		TargetUserId: targetUserId,
+		Count:        count,
+		AdminId:      adminId,
+		Time:         now,
+	})
+}
+
+// GetCorrections returns corrections of swears of the user, merges into
+// the user included, in the order they were made.
+func (mod *ModSwears) GetCorrections(userId string) []*StatsCorrection {
+	mod.corrections.mutex.Lock()
+	defer mod.corrections.mutex.Unlock()
+	corrections := []*StatsCorrection{}
+	for _, correction := range mod.corrections.Corrections {
+		if correction.UserId == userId || correction.TargetUserId == userId {
+			corrections = append(corrections, correction)
+		}
+	}
+	return corrections
+}
+
+// Records the correction and adds its events to stats. The correction is
+// kept only if it is saved to the corrections file and its events are
+// added.
+func (mod *ModSwears) addCorrection(events []SwearEvent, correction *StatsCorrection) int {
+	mod.corrections.mutex.Lock()
+	defer mod.corrections.mutex.Unlock()
+	mod.corrections.Corrections = append(mod.corrections.Corrections, correction)
+	err := writeCorrections(mod.correctionsFileName, mod.corrections)
+	if err != Success {
+		mod.corrections.Corrections = mod.corrections.Corrections[:len(mod.corrections.Corrections)-1]
+		return err
+	}
+	err = mod.stats.Add(events)
+	if err != Success {
+		mod.corrections.Corrections = mod.corrections.Corrections[:len(mod.corrections.Corrections)-1]
+		writeCorrections(mod.correctionsFileName, mod.corrections)
+	}
+	return err
+}
+
+// Returns swears matching the query which are left after reversals.
+func (mod *ModSwears) remainingSwears(query StatsQuery) ([]SwearEvent, int) {
+	events, err := mod.stats.Query(query)
+	if err != Success {
+		return nil, err
+	}
+	return remainingSwearsOf(events), Success
+}
+
+// Sums events per kind of swear, so reversed swears cancel out. Returns
+// swears which are left in the order they were found.
+func remainingSwearsOf(events []SwearEvent) []SwearEvent {
+	keyToIndex := make(map[swearKey]int)
+	swears := []SwearEvent{}
+	for _, event := range events {
+		key := swearKey{
+			channelId: event.ChannelId,
+			rule:      event.Rule,
+			text:      event.Text,
+			messageTs: event.MessageTs,
+			category:  event.Category,
+			time:      event.Time.UnixNano(),
+		}
+		i, ok := keyToIndex[key]
+		if !ok {
+			i = len(swears)
+			keyToIndex[key] = i
+			swear := event
+			swear.Count, swear.Score = 0, 0
+			swears = append(swears, swear)
+		}
+		swears[i].Count += event.Count
+		swears[i].Score += event.Score
+	}
+	remaining := swears[:0]
+	for _, swear := range swears {
+		if swear.Count > 0 {
+			remaining = append(remaining, swear)
+		}
+	}
+	return remaining
+}
+
+func reversalOf(event SwearEvent) SwearEvent {
+	event.Count = -event.Count
+	event.Score = -event.Score
+	event.Correction = true
+	return event
+}
+
+// Must be called with mutex of corrections held.
+func writeCorrections(fileName string, corrections *StatsCorrections) int {
+	bytes, err := json.MarshalIndent(corrections, "", "    ")
+	if err == nil {
+		err = utils.WriteFileAtomic(fileName, bytes)
+	}
+	if err != nil {
+		log.Printf("ModSwears: Cannot write corrections to file '%s'\n", fileName)
+		return CorrectionsSaveErr
+	}
+	return Success
+}
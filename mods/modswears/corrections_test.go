@@ -0,0 +1,148 @@
+package modswears
+
+import (
+	"../../utils"
+	"os"
+	"testing"
+)
+
+func TestCorrectStats(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+	defer os.Remove(tmpFilePath + ".corrections")
+	defer os.Remove(tmpFilePath + ".jar")
+
+	mod := createStats(t, tmpFilePath)
+	mod.config.RankSortBy = RankSortByScore
+	mod.correctionsFileName = tmpFilePath + ".corrections"
+	mod.jarFileName = tmpFilePath + ".jar"
+	assertLoadCorrections(t, mod)
+	assertLoadJar(t, mod)
+	assertAddSwearCount(t, mod, 1, 2016, "user1", 3)
+	assertAddSwears(t, mod, 2, 2016, "user1", "a", "b")
+	assertAddSwearCount(t, mod, 2, 2016, "user2", 1)
+
+	assertCorrectStats(t, 1, func() (int, int) {
+		return mod.PardonSwears("user1", 1, 2, 2016, "admin1", utils.TimeClock.Now())
+	})
+	assertCorrectStats(t, 3, func() (int, int) {
+		return mod.PardonSwears("user1", 5, 1, 2016, "admin1", utils.TimeClock.Now())
+	})
+	assertMonthlyRank(t, mod, 2, 2016, []*UserStats{
+		&UserStats{UserId: "user1", SwearCount: 1, Score: 2, Categories: map[string]int{"vulgar": 1}},
+		&UserStats{UserId: "user2", SwearCount: 1, Score: 1},
+	})
+	assertMonthlyRank(t, mod, 1, 2016, []*UserStats{})
+	assertTopSwears(t, mod, StatsQuery{}, 10, []*WordStats{
+		&WordStats{Word: "b", SwearCount: 1, Score: 2},
+	})
+	assertChannelRank(t, mod, StatsQuery{}, []*ChannelStats{
+		&ChannelStats{ChannelId: "channel1", SwearCount: 1, Score: 2},
+	})
+	assertJarBalance(t, mod, "user1", JarBalance{Fines: 2})
+	_, err := mod.PardonSwears("user1", 1, 1, 2016, "admin1", utils.TimeClock.Now())
+	if err != NoSwearsToCorrectErr {
+		t.Fatalf("Expected error %d when pardoning no swears, got %d", NoSwearsToCorrectErr, err)
+	}
+
+	assertCorrectStats(t, 1, func() (int, int) {
+		return mod.MergeUser("user1", "user2", "admin2", utils.TimeClock.Now())
+	})
+	assertTotalRank(t, mod, []*UserStats{
+		&UserStats{UserId: "user2", SwearCount: 2, Score: 3, Categories: map[string]int{"vulgar": 1}},
+	})
+	assertTopSwears(t, mod, StatsQuery{UserId: "user2"}, 10, []*WordStats{
+		&WordStats{Word: "b", SwearCount: 1, Score: 2},
+	})
+	assertChannelRank(t, mod, StatsQuery{}, []*ChannelStats{
+		&ChannelStats{ChannelId: "channel1", SwearCount: 1, Score: 2},
+	})
+	assertJarBalance(t, mod, "user1", JarBalance{})
+	assertJarBalance(t, mod, "user2", JarBalance{Fines: 3})
+	assertCorrectStats(t, 2, func() (int, int) {
+		return mod.ResetStats("user2", "admin1", utils.TimeClock.Now())
+	})
+	assertTotalRank(t, mod, []*UserStats{})
+	assertTopSwears(t, mod, StatsQuery{}, 10, []*WordStats{})
+	assertChannelRank(t, mod, StatsQuery{}, []*ChannelStats{})
+	assertJarBalance(t, mod, "", JarBalance{})
+
+	assertLoadCorrections(t, mod)
+	assertCorrections(t, mod, "user1", CorrectionPardon, CorrectionPardon, CorrectionMerge)
+	assertCorrections(t, mod, "user2", CorrectionMerge, CorrectionReset)
+	correction := mod.GetCorrections("user2")[0]
+	if correction.UserId != "user1" || correction.TargetUserId != "user2" || correction.AdminId != "admin2" {
+		t.Fatalf("Expected merge of user1 into user2 by admin2, got %#v", correction)
+	}
+}
+
+func TestCorrectStatsNotSaved(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+
+	mod := createStats(t, tmpFilePath)
+	mod.corrections = newStatsCorrections()
+	mod.correctionsFileName = tmpFilePath + ".missing/corrections"
+	assertAddSwears(t, mod, 2, 2016, "user1", "a")
+
+	_, err := mod.ResetStats("user1", "admin1", utils.TimeClock.Now())
+	if err != CorrectionsSaveErr {
+		t.Fatalf("Expected error %d when corrections cannot be saved, got %d", CorrectionsSaveErr, err)
+	}
+	assertCorrections(t, mod, "user1")
+	assertTotalRank(t, mod, []*UserStats{
+		&UserStats{UserId: "user1", SwearCount: 1, Score: 2, Categories: map[string]int{"vulgar": 1}},
+	})
+}
+
+func TestFormatCorrections(t *testing.T) {
+	config := NewModSwearsConfig()
+	now := utils.NewLocalDateTime(2016, 3, 10, 12, 0, 0)
+	names := map[string]string{"U1": "john", "U2": "jane", "A1": "admin"}
+	corrections := []*StatsCorrection{
+		&StatsCorrection{Kind: CorrectionPardon, UserId: "U1", Month: 2, Year: 2016, Count: 2, AdminId: "A1", Time: now},
+		&StatsCorrection{Kind: CorrectionMerge, UserId: "U2", TargetUserId: "U1", Count: 3, AdminId: "A1", Time: now},
+		&StatsCorrection{Kind: CorrectionReset, UserId: "U1", Count: 5, AdminId: "A1", Time: now},
+	}
+	expected := "*Corrections of john*\n" +
+		"2016-03-10 admin pardoned 2 swears of john in February 2016\n" +
+		"2016-03-10 admin moved 3 swears of jane to john\n" +
+		"2016-03-10 admin reset stats of john, 5 swears taken back"
+	actual := formatCorrections(config, "john", names, corrections)
+	if actual != expected {
+		t.Fatalf("Expected corrections '%s', got '%s'", expected, actual)
+	}
+}
+
+func assertLoadCorrections(t *testing.T, mod *ModSwears) {
+	err := mod.LoadCorrections()
+	if err != Success {
+		t.Fatalf("Expected to load corrections without errors, got %v", err)
+	}
+}
+
+func assertCorrectStats(t *testing.T, expected int, correct func() (int, int)) {
+	count, err := correct()
+	if err != Success {
+		t.Fatalf("Expected no error when correcting stats but got %v", err)
+	}
+	if count != expected {
+		t.Fatalf("Expected %d swears to be corrected, got %d", expected, count)
+	}
+}
+
+func assertCorrections(t *testing.T, mod *ModSwears, userId string, expected ...string) {
+	corrections := mod.GetCorrections(userId)
+	actual := make([]string, len(corrections))
+	for i, correction := range corrections {
+		actual[i] = correction.Kind
+	}
+	if len(actual) != len(expected) {
+		t.Fatalf("Expected corrections %v of %s, got %v", expected, userId, actual)
+	}
+	for i := range actual {
+		if actual[i] != expected[i] {
+			t.Fatalf("Expected corrections %v of %s, got %v", expected, userId, actual)
+		}
+	}
+}
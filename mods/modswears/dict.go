@@ -2,74 +2,321 @@ package modswears
 
 import (
 	"../../dictmatch"
+	"../../utils"
 	"bufio"
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
+	"sort"
 	"strings"
 )
 
 const (
-	DictFileReadErr    = 21
-	InvalidWildcardErr = 22
-	AddRuleConflictErr = 23
-	AddRuleSaveErr     = 24
+	DictFileReadErr      = 21
+	InvalidWildcardErr   = 22
+	AddRuleConflictErr   = 23
+	AddRuleSaveErr       = 24
+	RuleNotFoundErr      = 25
+	ExceptionConflictErr = 26
 )
 
 func (mod *ModSwears) AddRule(rule string) int {
+	mod.rulesMutex.Lock()
+	defer mod.rulesMutex.Unlock()
 	file, fileReadErr := os.OpenFile(mod.dictFileName, os.O_RDWR|os.O_APPEND, 0666)
 	if fileReadErr != nil {
 		log.Printf("ModSwears: cannot open swear dictionary file: %v\n", fileReadErr)
 		return DictFileReadErr
 	}
 	defer file.Close()
-	normRule := normalizeWord(rule)
-	confilctErr := mod.dict.AddEntry(normRule)
+	rules := mod.getRules().clone()
+	normRule, err := rules.addEntry(rule)
+	if err != Success {
+		return err
+	}
+	_, saveErr := file.WriteString(fmt.Sprintf("%s\n", normRule))
+	if saveErr != nil {
+		log.Printf("ModSwears: cannot write string '%s' to swear dictionary file: %v\n", normRule, saveErr)
+		return AddRuleSaveErr
+	}
+	mod.setRules(rules)
+	mod.updateDictModTime()
+	return Success
+}
+
+// Adds rule to the dictionary or to regex rules, returns normalized rule.
+func (rules *swearRules) addEntry(rule string) (string, int) {
+	if source := strings.TrimSpace(rule); isRegexRule(source) {
+		return source, rules.addRegexRule(source)
+	}
+	normRule := rules.normalizer.normalizeRule(rule)
+	if isLemmaRule(normRule) {
+		return normRule, rules.addLemmaRule(normRule)
+	}
+	confilctErr := rules.dict.AddEntry(normRule)
 	if confilctErr != nil {
 		log.Printf("ModSwears: add rule: %s\n", confilctErr.Desc)
 		if confilctErr.ErrType == dictmatch.InvalidWildardPlacementErr {
-			return InvalidWildcardErr
+			return normRule, InvalidWildcardErr
+		}
+		if confilctErr.ErrType == dictmatch.EntryOverlappedByExceptionErr ||
+			confilctErr.ErrType == dictmatch.ExceptionOverlapsEntryErr {
+			return normRule, ExceptionConflictErr
 		}
 
-		return AddRuleConflictErr
+		return normRule, AddRuleConflictErr
 	}
-	_, saveErr := file.WriteString(fmt.Sprintf("%s\n", normRule))
+	return normRule, Success
+}
+
+func (mod *ModSwears) AddException(rule string) int {
+	return mod.AddRule(dictmatch.ExceptionPrefix + rule)
+}
+
+func (mod *ModSwears) RemoveRule(rule string) int {
+	mod.rulesMutex.Lock()
+	defer mod.rulesMutex.Unlock()
+	rules := mod.getRules().clone()
+	entry, ok := rules.findEntry(rule)
+	if !ok {
+		log.Printf("ModSwears: remove rule: rule '%s' not found\n", rule)
+		return RuleNotFoundErr
+	}
+	if isLemmaRule(entry) && !isLemmaRule(rules.normalizer.normalizeRule(rule)) {
+		log.Printf("ModSwears: remove rule: '%s' is a form of lemma rule '%s'\n", rule, entry)
+		return LemmaFormRemoveErr
+	}
+	content, fileReadErr := ioutil.ReadFile(mod.dictFileName)
+	if fileReadErr != nil {
+		log.Printf("ModSwears: cannot read swear dictionary file: %v\n", fileReadErr)
+		return DictFileReadErr
+	}
+	var buffer bytes.Buffer
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		lineEntry, _ := rules.findEntry(line)
+		if lineEntry != entry {
+			buffer.WriteString(line)
+		}
+	}
+	saveErr := utils.WriteFileAtomic(mod.dictFileName, buffer.Bytes())
 	if saveErr != nil {
-		log.Printf("ModSwears: cannot write string '%s' to swear dictionary file: %v\n", normRule, saveErr)
+		log.Printf("ModSwears: cannot remove rule '%s' from swear dictionary file: %v\n", entry, saveErr)
 		return AddRuleSaveErr
 	}
+	if !rules.removeRegexRule(entry) && !rules.removeLemmaRule(entry) {
+		rules.dict.RemoveEntry(entry)
+	}
+	mod.setRules(rules)
+	mod.updateDictModTime()
 	return Success
 }
 
+// Finds dictionary entry, regex rule or lemma rule equal to the rule.
+// Forms of lemma rules are found as their lemma rule.
+func (rules *swearRules) findEntry(rule string) (string, bool) {
+	if source := strings.TrimSpace(rule); isRegexRule(source) {
+		return source, rules.findRegexRule(source) >= 0
+	}
+	normRule := rules.normalizer.normalizeRule(rule)
+	if isLemmaRule(normRule) {
+		_, ok := rules.lemmas[normRule]
+		return normRule, ok
+	}
+	entry, ok := rules.dict.FindEntry(normRule)
+	return rules.ruleOf(entry), ok
+}
+
+// RuleCount returns the number of rules, counting a lemma rule once
+// regardless of its forms.
+func (mod *ModSwears) RuleCount() int {
+	rules := mod.getRules()
+	return rules.dict.Len() - len(rules.lemmaForms) + len(rules.regexRules) + len(rules.lemmas)
+}
+
+func (mod *ModSwears) ListRules(prefix string) []string {
+	rules := mod.getRules()
+	markers := dictmatch.ExceptionPrefix + dictmatch.SubstringPrefix + regexRuleMarker + lemmaRuleMarker
+	prefix = rules.normalizer.normalizeRule(strings.TrimLeft(prefix, markers))
+	entries := []string{}
+	for _, entry := range rules.dict.Entries() {
+		if _, ok := rules.lemmaForms[entry]; !ok {
+			entries = append(entries, entry)
+		}
+	}
+	for _, rule := range rules.regexRules {
+		entries = append(entries, rule.source)
+	}
+	for rule := range rules.lemmas {
+		entries = append(entries, rule)
+	}
+	found := []string{}
+	for _, entry := range entries {
+		rule := strings.TrimLeft(entry, markers)
+		if strings.HasPrefix(rule, prefix) {
+			found = append(found, entry)
+		}
+	}
+	sort.Strings(found)
+	return found
+}
+
+func (mod *ModSwears) WhichRule(word string) (string, bool) {
+	rules := mod.getRules()
+	words := strings.Fields(word)
+	if len(words) > 1 {
+		for i, word := range words {
+			words[i] = rules.normalizer.normalizeSwear(word)
+		}
+		length, rule := rules.dict.MatchPhrase(words)
+		if length != len(words) {
+			return "", false
+		}
+		return rule, true
+	}
+	normWord := rules.normalizer.normalizeSwear(word)
+	if normWord == "" {
+		return "", false
+	}
+	success, rule := rules.dict.MatchEntry(normWord)
+	if !success && !rules.dict.Excepted(normWord) {
+		return rules.matchRegexRules(normWord)
+	}
+	return rules.ruleOf(rule), success
+}
+
+// Swear found in a message together with the rule that matched it.
+type SwearMatch struct {
+	Text string
+	Rule string
+}
+
+// Found swear with its offset in message.
+type foundSwear struct {
+	SwearMatch
+	start int
+}
+
+type ByFoundSwearStart []foundSwear
+
+func (a ByFoundSwearStart) Len() int {
+	return len(a)
+}
+
+func (a ByFoundSwearStart) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
+}
+
+func (a ByFoundSwearStart) Less(i, j int) bool {
+	return a[i].start < a[j].start
+}
+
 func (mod *ModSwears) FindSwears(message string) []string {
 	swears := make([]string, 0)
-	words := strings.Fields(message)
-	for _, word := range words {
-		word = normalizeWord(word)
-		success, _ := mod.dict.Match(word)
+	for _, swear := range mod.FindSwearMatches(message) {
+		swears = append(swears, swear.Text)
+	}
+	return swears
+}
+
+// FindSwearMatches matches phrase rules and then every remaining word of
+// the message against word rules. Finally the whole message is scanned for
+// substring rules. Words already matched are not counted again.
+func (mod *ModSwears) FindSwearMatches(message string) []SwearMatch {
+	rules := mod.getRules()
+	spans := rules.tokenizer.tokenize(message)
+	normWords := make([]string, len(spans))
+	for i, span := range spans {
+		normWords[i] = rules.normalizer.normalizeSwear(span.text)
+	}
+	matched := make([]bool, len(spans))
+	found := []foundSwear{}
+	for i := 0; i < len(spans); i++ {
+		length, rule := rules.dict.MatchPhrase(normWords[i:])
+		if length > 0 {
+			phrase := message[spans[i].start:spans[i+length-1].end]
+			found = append(found, newFoundSwear(phrase, rule, spans[i].start))
+			for j := i; j < i+length; j++ {
+				matched[j] = true
+			}
+			i += length - 1
+			continue
+		}
+		if normWords[i] == "" {
+			continue
+		}
+		success, rule := rules.dict.MatchEntry(normWords[i])
+		rule = rules.ruleOf(rule)
+		if !success && !rules.dict.Excepted(normWords[i]) {
+			rule, success = rules.matchRegexRules(normWords[i])
+		}
 		if success {
-			swears = append(swears, word)
+			found = append(found, newFoundSwear(spans[i].text, rule, spans[i].start))
+			matched[i] = true
 		}
 	}
+	found = append(found, rules.scanSwears(message, spans, matched)...)
+	sort.Stable(ByFoundSwearStart(found))
+	swears := make([]SwearMatch, 0)
+	for _, swear := range found {
+		swears = append(swears, swear.SwearMatch)
+	}
 	return swears
 }
 
+func (rules *swearRules) scanSwears(message string, spans []wordSpan, matched []bool) []foundSwear {
+	found := []foundSwear{}
+	text, offsets := rules.normalizer.normalizeText(message)
+	lastEnd := 0
+	for _, occurrence := range rules.dict.Scan(text) {
+		start := offsets[occurrence.Start]
+		end := offsets[occurrence.End]
+		i := findSpan(spans, start)
+		if start < lastEnd || i < 0 || matched[i] {
+			continue
+		}
+		if rules.dict.Excepted(rules.normalizer.normalizeSwear(spans[i].text)) {
+			continue
+		}
+		found = append(found, newFoundSwear(spans[i].text, occurrence.Entry, start))
+		lastEnd = end
+	}
+	return found
+}
+
+func newFoundSwear(text string, rule string, start int) foundSwear {
+	return foundSwear{
+		SwearMatch: SwearMatch{
+			Text: normalizeWord(text),
+			Rule: rule,
+		},
+		start: start,
+	}
+}
+
+// LoadSwears builds new rules from the dictionary file and swaps them in
+// place of the current ones.
 func (mod *ModSwears) LoadSwears() int {
+	mod.rulesMutex.Lock()
+	defer mod.rulesMutex.Unlock()
 	file, err := os.Open(mod.dictFileName)
 	if err != nil {
 		log.Printf("ModSwears: Error opening swear dictionary file: %v\n", err)
 		return DictFileReadErr
 	}
 	defer file.Close()
+	rules := newSwearRules(mod.config, mod.getRules().ruleInfos)
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
-		word := normalizeWord(scanner.Text())
-		mod.dict.AddEntry(word)
+		rules.addEntry(scanner.Text())
 	}
 	if err := scanner.Err(); err != nil {
 		log.Printf("ModSwears: Error reading from swear dictionary file: %v\n", err)
 		return DictFileReadErr
 	}
+	mod.setRules(rules)
+	mod.updateDictModTime()
 	return Success
 }
 
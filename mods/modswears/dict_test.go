@@ -32,6 +32,143 @@ func TestAddRule(t *testing.T) {
 	assertFindSwears(t, mod, "Test FGH abcd fghi fgi", expected)
 }
 
+func TestAddRuleInnerWildcards(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := createSwears(t, tmpFileName)
+	assertRemoveRule(t, mod, "abb*")
+	assertAddRule(t, mod, "*xy")
+	assertAddRule(t, mod, "f*g")
+	expected := []string{"xy", "wxy", "fg", "fooog"}
+	assertFindSwears(t, mod, "xy wxy xyz fg fooog fgh", expected)
+}
+
+func TestAddException(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := createSwears(t, tmpFileName)
+	assertAddException(t, mod, "Abbey")
+	assertFindSwears(t, mod, "abba abbey abbeys", []string{"abba", "abbeys"})
+	assertDictFile(t, tmpFileName, "a\nabcd\nabb*\n!abbey\n")
+	assertListRules(t, mod, "abbe", []string{"!abbey"})
+	assertRemoveRule(t, mod, "!abbey")
+	assertFindSwears(t, mod, "abbey", []string{"abbey"})
+}
+
+func TestAddExceptionConflictErr(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := createSwears(t, tmpFileName)
+	assertAddExceptionErr(t, mod, "abcd", ExceptionConflictErr)
+	assertAddExceptionErr(t, mod, "ab*", ExceptionConflictErr)
+	assertAddException(t, mod, "xy*")
+	assertAddExceptionErr(t, mod, "xyz", AddRuleConflictErr)
+	assertAddRuleErr(t, mod, "xyz", ExceptionConflictErr)
+}
+
+func TestFindSubstringSwears(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := createSwears(t, tmpFileName)
+	assertAddRule(t, mod, "~xyz")
+	assertAddRule(t, mod, "~qq")
+	assertAddException(t, mod, "wxyzw")
+	expected := []string{"xyz", "abcd", "axyzb", "xyzxyz", "xyzxyz", "qq-abba", "ąbcxyż"}
+	message := "xyz abcd axyzb xy wxyzw xyzxyz qq-abba ąbcxyż"
+	assertFindSwears(t, mod, message, expected)
+}
+
+func TestAddSubstringRuleConflictErr(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := createSwears(t, tmpFileName)
+	assertAddRuleErr(t, mod, "~bc", AddRuleConflictErr)
+	assertAddRuleErr(t, mod, "~x*y", InvalidWildcardErr)
+	assertAddRule(t, mod, "~xyz")
+	assertAddRuleErr(t, mod, "wxyz", AddRuleConflictErr)
+}
+
+func TestFindPhraseSwears(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := createSwears(t, tmpFileName)
+	assertAddRule(t, mod, "Xy  Abcd")
+	assertAddRule(t, mod, "xy z*")
+	assertAddRule(t, mod, "~qq")
+	assertDictFile(t, tmpFileName, "a\nabcd\nabb*\nxy abcd\nxy z*\n~qq\n")
+	expected := []string{"xy abcd", "abcd", "x.y zzqq", "a"}
+	assertFindSwears(t, mod, "xy abcd abcd xy x.y zzqq a xy", expected)
+}
+
+func TestRemoveRule(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := createSwears(t, tmpFileName)
+	assertRemoveRule(t, mod, "ABB*")
+	assertFindSwears(t, mod, "a abcd abba", []string{"a", "abcd"})
+	assertDictFile(t, tmpFileName, "a\nabcd\n")
+
+	assertAddRule(t, mod, "xy  abcd")
+	assertRemoveRule(t, mod, "xy abcd")
+	assertFindSwears(t, mod, "xy abcd", []string{"abcd"})
+	assertDictFile(t, tmpFileName, "a\nabcd\n")
+
+	mod = createSwears(t, tmpFileName)
+	assertFindSwears(t, mod, "a abcd abba", []string{"a", "abcd"})
+}
+
+func TestRemoveRuleNotFoundErr(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := createSwears(t, tmpFileName)
+	assertRemoveRuleErr(t, mod, "abb", RuleNotFoundErr)
+	assertRemoveRuleErr(t, mod, "abba", RuleNotFoundErr)
+	assertDictFile(t, tmpFileName, "a\nabcd\nabb*\n")
+}
+
+func TestRemoveRuleFileReadErr(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := createSwears(t, tmpFileName)
+	os.Remove(tmpFileName)
+	assertRemoveRuleErr(t, mod, "abcd", DictFileReadErr)
+	assertFindSwears(t, mod, "abcd", []string{"abcd"})
+}
+
+func TestListRules(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := createSwears(t, tmpFileName)
+	assertListRules(t, mod, "", []string{"a", "abb*", "abcd"})
+	assertListRules(t, mod, "AB", []string{"abb*", "abcd"})
+	assertListRules(t, mod, "abc", []string{"abcd"})
+	assertListRules(t, mod, "x", []string{})
+}
+
+func TestWhichRule(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := createSwears(t, tmpFileName)
+	assertWhichRule(t, mod, "Abbbey", "abb*", true)
+	assertWhichRule(t, mod, "a.b.c.d", "abcd", true)
+	assertWhichRule(t, mod, "abc", "", false)
+	assertAddRule(t, mod, "xy z*")
+	assertWhichRule(t, mod, "Xy  zzz", "xy z*", true)
+	assertWhichRule(t, mod, "xy zzz abcd", "", false)
+	assertWhichRule(t, mod, "ab xy", "", false)
+}
+
 func TestAddRuleFileReadErr(t *testing.T) {
 	tmpFileName := createTmpDict(t)
 	defer os.Remove(tmpFileName)
@@ -48,7 +185,7 @@ func TestAddRuleConflictErr(t *testing.T) {
 	mod := createSwears(t, tmpFileName)
 	assertAddRuleErr(t, mod, "abc*", AddRuleConflictErr)
 	assertAddRuleErr(t, mod, "ab*", AddRuleConflictErr)
-	assertAddRuleErr(t, mod, "*", AddRuleConflictErr)
+	assertAddRuleErr(t, mod, "*bc", AddRuleConflictErr)
 }
 
 func TestAddRuleInvalidWildcardErr(t *testing.T) {
@@ -56,11 +193,11 @@ func TestAddRuleInvalidWildcardErr(t *testing.T) {
 	defer os.Remove(tmpFileName)
 
 	mod := createSwears(t, tmpFileName)
-	assertAddRuleErr(t, mod, "xx*x", InvalidWildcardErr)
-	assertAddRuleErr(t, mod, "*dd*", InvalidWildcardErr)
 	assertAddRuleErr(t, mod, "**x1", InvalidWildcardErr)
+	assertAddRuleErr(t, mod, "x**1", InvalidWildcardErr)
 	assertAddRuleErr(t, mod, "x2**", InvalidWildcardErr)
 	assertAddRuleErr(t, mod, "**", InvalidWildcardErr)
+	assertAddRuleErr(t, mod, "*", InvalidWildcardErr)
 }
 
 func createSwears(t *testing.T, tmpFilePath string) *ModSwears {
@@ -111,3 +248,55 @@ func assertAddRuleErr(t *testing.T, mod *ModSwears, r string, expected int) {
 		t.Fatalf("Expected error %v when adding rule '%s', got %v", expected, r, err)
 	}
 }
+
+func assertRemoveRule(t *testing.T, mod *ModSwears, r string) {
+	err := mod.RemoveRule(r)
+	if err != Success {
+		t.Fatalf("Expected no errors when removing rule '%s', got: %v", r, err)
+	}
+}
+
+func assertRemoveRuleErr(t *testing.T, mod *ModSwears, r string, expected int) {
+	err := mod.RemoveRule(r)
+	if err != expected {
+		t.Fatalf("Expected error %v when removing rule '%s', got %v", expected, r, err)
+	}
+}
+
+func assertDictFile(t *testing.T, fileName string, expected string) {
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != expected {
+		t.Fatalf("Expected dictionary file content %#v, got %#v", expected, string(content))
+	}
+}
+
+func assertListRules(t *testing.T, mod *ModSwears, prefix string, expected []string) {
+	actual := mod.ListRules(prefix)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected rules %#v for prefix '%s', got %#v", expected, prefix, actual)
+	}
+}
+
+func assertWhichRule(t *testing.T, mod *ModSwears, w string, expected string, expectedOk bool) {
+	actual, ok := mod.WhichRule(w)
+	if ok != expectedOk || actual != expected {
+		t.Fatalf("Expected rule '%s' (%v) for word '%s', got '%s' (%v)", expected, expectedOk, w, actual, ok)
+	}
+}
+
+func assertAddException(t *testing.T, mod *ModSwears, r string) {
+	err := mod.AddException(r)
+	if err != Success {
+		t.Fatalf("Expected no errors when adding exception '%s', got: %v", r, err)
+	}
+}
+
+func assertAddExceptionErr(t *testing.T, mod *ModSwears, r string, expected int) {
+	err := mod.AddException(r)
+	if err != expected {
+		t.Fatalf("Expected error %v when adding exception '%s', got %v", expected, r, err)
+	}
+}
@@ -0,0 +1,117 @@
+package modswears
+
+import (
+	"../../mods"
+	"../../utils"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (mod *ModSwears) ProcessMessageEdit(
+	message string,
+	userId string,
+	channelId string,
+	messageTs string) *mods.Response {
+
+	err := mod.ChangeMessageSwears(userId, channelId, messageTs, mod.FindSwearMatches(message))
+	if err != Success {
+		return response(getErrMessage(err, mod.config), channelId)
+	}
+	return nil
+}
+
+func (mod *ModSwears) ProcessMessageDelete(channelId string, messageTs string) *mods.Response {
+	if !mod.config.ReverseDeletedSwears {
+		return nil
+	}
+	err := mod.ChangeMessageSwears("", channelId, messageTs, []SwearMatch{})
+	if err != Success {
+		return response(getErrMessage(err, mod.config), channelId)
+	}
+	return nil
+}
+
+// ChangeMessageSwears makes swears counted for the message equal to the
+// given swears. Swears no longer in the message are reversed by events
+// with negative count and score, new swears are added. All of them happen
+// when the message was sent, so they count in the month of the message.
+// Swears are compared with detections and their reversals only, swears
+// taken back by a correction are neither added again nor reversed twice.
+func (mod *ModSwears) ChangeMessageSwears(
+	userId string,
+	channelId string,
+	messageTs string,
+	swears []SwearMatch) int {
+
+	events, err := mod.stats.Query(StatsQuery{ChannelId: channelId, MessageTs: messageTs})
+	if err != Success {
+		return err
+	}
+	ruleCounts := make(map[string]int)
+	ruleEvents := make(map[string]SwearEvent)
+	for _, event := range events {
+		if event.Correction {
+			continue
+		}
+		ruleCounts[event.Rule] += event.Count
+		if event.Count > 0 {
+			ruleEvents[event.Rule] = event
+		}
+		userId = event.UserId
+	}
+	ruleLeft := make(map[string]int)
+	for _, event := range events {
+		if event.UserId == userId {
+			ruleLeft[event.Rule] += event.Count
+		}
+	}
+	messageTime, ok := parseMessageTs(messageTs)
+	if !ok {
+		messageTime = utils.TimeClock.Now()
+	}
+	changes := []SwearEvent{}
+	for _, event := range mod.swearEvents(userId, channelId, messageTs, messageTime, swears) {
+		if ruleCounts[event.Rule] > 0 {
+			ruleCounts[event.Rule]--
+		} else {
+			changes = append(changes, event)
+		}
+	}
+	rules := []string{}
+	for rule := range ruleCounts {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	for _, rule := range rules {
+		for i := 0; i < ruleCounts[rule] && i < ruleLeft[rule]; i++ {
+			reversal := ruleEvents[rule]
+			reversal.Count = -reversal.Count
+			reversal.Score = -reversal.Score
+			changes = append(changes, reversal)
+		}
+	}
+	if len(changes) == 0 {
+		return Success
+	}
+	return mod.stats.Add(changes)
+}
+
+// Returns time of a message from its timestamp, which is unix time in
+// seconds with microseconds after a dot.
+func parseMessageTs(messageTs string) (time.Time, bool) {
+	parts := strings.SplitN(messageTs, ".", 2)
+	seconds, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	micros := int64(0)
+	if len(parts) == 2 {
+		micros, err = strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+	}
+	return time.Unix(seconds, micros*int64(time.Microsecond)), true
+}
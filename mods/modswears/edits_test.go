@@ -0,0 +1,104 @@
+package modswears
+
+import (
+	"../../utils"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestChangeMessageSwears(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+
+	mod := createStats(t, tmpFilePath)
+	messageTs := "1455537600.000100"
+	messageTime, _ := parseMessageTs(messageTs)
+	err := mod.AddSwears(messageTime, "user1", "channel1", messageTs, swearMatches("a", "a", "b"))
+	if err != Success {
+		t.Fatalf("Expected no error when adding swears but got %v", err)
+	}
+
+	assertChangeMessageSwears(t, mod, "user1", messageTs, "a", "c")
+	assertMonthlyRank(t, mod, 2, 2016, []*UserStats{
+		&UserStats{UserId: "user1", SwearCount: 2, Score: 4, Categories: map[string]int{"vulgar": 2}},
+	})
+	assertTopSwears(t, mod, monthQuery(2, 2016), 10, []*WordStats{
+		&WordStats{Word: "a", SwearCount: 1, Score: 2},
+		&WordStats{Word: "c", SwearCount: 1, Score: 2},
+	})
+	assertChangeMessageSwears(t, mod, "user1", messageTs, "a", "c")
+	assertMonthlyRank(t, mod, 2, 2016, []*UserStats{
+		&UserStats{UserId: "user1", SwearCount: 2, Score: 4, Categories: map[string]int{"vulgar": 2}},
+	})
+
+	mod.config.ReverseDeletedSwears = false
+	mod.ProcessMessageDelete("channel1", messageTs)
+	assertMonthlyRank(t, mod, 2, 2016, []*UserStats{
+		&UserStats{UserId: "user1", SwearCount: 2, Score: 4, Categories: map[string]int{"vulgar": 2}},
+	})
+	mod.config.ReverseDeletedSwears = true
+	mod.ProcessMessageDelete("channel1", messageTs)
+	assertMonthlyRank(t, mod, 2, 2016, []*UserStats{})
+	assertTopSwears(t, mod, StatsQuery{}, 10, []*WordStats{})
+}
+
+func TestChangePardonedMessageSwears(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+	defer os.Remove(tmpFilePath + ".corrections")
+
+	mod := createStats(t, tmpFilePath)
+	mod.correctionsFileName = tmpFilePath + ".corrections"
+	assertLoadCorrections(t, mod)
+	messageTs := "1455537600.000100"
+	assertChangeMessageSwears(t, mod, "user1", messageTs, "a", "b")
+	assertCorrectStats(t, 1, func() (int, int) {
+		return mod.PardonSwears("user1", 1, 2, 2016, "admin1", utils.TimeClock.Now())
+	})
+
+	assertChangeMessageSwears(t, mod, "user1", messageTs, "a", "b")
+	assertTopSwears(t, mod, StatsQuery{}, 10, []*WordStats{
+		&WordStats{Word: "b", SwearCount: 1, Score: 2},
+	})
+	assertChangeMessageSwears(t, mod, "user1", messageTs, "b")
+	assertTopSwears(t, mod, StatsQuery{}, 10, []*WordStats{
+		&WordStats{Word: "b", SwearCount: 1, Score: 2},
+	})
+	assertChangeMessageSwears(t, mod, "user1", messageTs, "a", "b")
+	assertTopSwears(t, mod, StatsQuery{}, 10, []*WordStats{
+		&WordStats{Word: "b", SwearCount: 1, Score: 2},
+	})
+	mod.ProcessMessageDelete("channel1", messageTs)
+	assertMonthlyRank(t, mod, 2, 2016, []*UserStats{})
+	assertTopSwears(t, mod, StatsQuery{}, 10, []*WordStats{})
+}
+
+func TestChangeMessageWithoutSwears(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+
+	mod := createStats(t, tmpFilePath)
+	assertChangeMessageSwears(t, mod, "user2", "1455537600.000200", "b")
+	assertMonthlyRank(t, mod, 2, 2016, []*UserStats{
+		&UserStats{UserId: "user2", SwearCount: 1, Score: 2, Categories: map[string]int{"vulgar": 1}},
+	})
+}
+
+func TestParseMessageTs(t *testing.T) {
+	actual, ok := parseMessageTs("1455537600.000100")
+	expected := time.Unix(1455537600, 100000)
+	if !ok || !actual.Equal(expected) {
+		t.Fatalf("Expected message time %v, got %v", expected, actual)
+	}
+	if _, ok := parseMessageTs("1455537600.x"); ok {
+		t.Fatal("Expected invalid message timestamp not to be parsed")
+	}
+}
+
+func assertChangeMessageSwears(t *testing.T, mod *ModSwears, userId string, messageTs string, rules ...string) {
+	err := mod.ChangeMessageSwears(userId, "channel1", messageTs, swearMatches(rules...))
+	if err != Success {
+		t.Fatalf("Expected no error when changing message swears but got %v", err)
+	}
+}
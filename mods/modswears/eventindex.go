@@ -0,0 +1,117 @@
+package modswears
+
+import (
+	"time"
+)
+
+// Events kept in memory in the order they were added, indexed by local
+// month, by user and by message. A query reads only the events of
+// the smallest index list it can use instead of all events.
+type eventIndex struct {
+	events   []SwearEvent
+	months   map[int][]int
+	users    map[string][]int
+	messages map[messageKey][]int
+	first    int
+	last     int
+}
+
+type messageKey struct {
+	channelId string
+	messageTs string
+}
+
+func newEventIndex() *eventIndex {
+	return &eventIndex{
+		events:   []SwearEvent{},
+		months:   map[int][]int{},
+		users:    map[string][]int{},
+		messages: map[messageKey][]int{},
+		first:    0,
+		last:     -1,
+	}
+}
+
+func (index *eventIndex) add(events ...SwearEvent) {
+	for _, event := range events {
+		position := len(index.events)
+		index.events = append(index.events, event)
+		month := eventMonthKey(event.Time)
+		if index.first > index.last {
+			index.first, index.last = month, month
+		} else if month < index.first {
+			index.first = month
+		} else if month > index.last {
+			index.last = month
+		}
+		index.months[month] = append(index.months[month], position)
+		index.users[event.UserId] = append(index.users[event.UserId], position)
+		if event.MessageTs != "" {
+			key := messageKey{event.ChannelId, event.MessageTs}
+			index.messages[key] = append(index.messages[key], position)
+		}
+	}
+}
+
+func (index *eventIndex) query(query StatsQuery) []SwearEvent {
+	events := []SwearEvent{}
+	for _, positions := range index.candidates(query) {
+		for _, position := range positions {
+			if query.matches(index.events[position]) {
+				events = append(events, index.events[position])
+			}
+		}
+	}
+	return events
+}
+
+// Returns positions of events which can match the query, read either from
+// the message, the user or the month index, whichever is the shortest.
+func (index *eventIndex) candidates(query StatsQuery) [][]int {
+	if query.MessageTs != "" && query.ChannelId != "" {
+		return [][]int{index.messages[messageKey{query.ChannelId, query.MessageTs}]}
+	}
+	first, last := index.first, index.last
+	if !query.From.IsZero() && eventMonthKey(query.From) > first {
+		first = eventMonthKey(query.From)
+	}
+	if !query.To.IsZero() && eventMonthKey(query.To.Add(-time.Nanosecond)) < last {
+		last = eventMonthKey(query.To.Add(-time.Nanosecond))
+	}
+	months := [][]int{}
+	count := 0
+	for month := first; month <= last; month++ {
+		if positions, ok := index.months[month]; ok {
+			months = append(months, positions)
+			count += len(positions)
+		}
+	}
+	if query.UserId != "" && len(index.users[query.UserId]) < count {
+		return [][]int{index.users[query.UserId]}
+	}
+	return months
+}
+
+// Returns the month keys of events in ascending order.
+func (index *eventIndex) monthKeys() []int {
+	keys := []int{}
+	for month := index.first; month <= index.last; month++ {
+		if _, ok := index.months[month]; ok {
+			keys = append(keys, month)
+		}
+	}
+	return keys
+}
+
+func (index *eventIndex) monthEvents(month int) []SwearEvent {
+	events := make([]SwearEvent, len(index.months[month]))
+	for i, position := range index.months[month] {
+		events[i] = index.events[position]
+	}
+	return events
+}
+
+func eventMonthKey(eventTime time.Time) int {
+	eventTime = eventTime.Local()
+	return eventTime.Year()*12 + int(eventTime.Month()) - 1
+}
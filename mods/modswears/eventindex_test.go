@@ -0,0 +1,42 @@
+package modswears
+
+import (
+	"../../utils"
+	"reflect"
+	"testing"
+)
+
+func TestEventIndexQuery(t *testing.T) {
+	index := newEventIndex()
+	jan := utils.NewLocalDateTime(2016, 1, 31, 23, 0, 0)
+	feb := utils.NewLocalDate(2016, 2, 1)
+	dec := utils.NewLocalDate(2015, 12, 5)
+	index.add(
+		SwearEvent{UserId: "user1", ChannelId: "c1", Time: jan, MessageTs: "1", Count: 1},
+		SwearEvent{UserId: "user2", ChannelId: "c1", Time: feb, MessageTs: "2", Count: 1},
+		SwearEvent{UserId: "user1", ChannelId: "c2", Time: feb, MessageTs: "1", Count: 1})
+	index.add(SwearEvent{UserId: "user1", Time: dec, Count: 3})
+	index.add(SwearEvent{UserId: "user1", ChannelId: "c1", Time: jan, MessageTs: "1", Count: -1})
+
+	assertIndexQuery(t, index, StatsQuery{}, 3, 0, 4, 1, 2)
+	assertIndexQuery(t, index, StatsQuery{From: jan, To: feb}, 0, 4)
+	assertIndexQuery(t, index, StatsQuery{From: feb}, 1, 2)
+	assertIndexQuery(t, index, StatsQuery{To: jan}, 3)
+	assertIndexQuery(t, index, StatsQuery{UserId: "user1"}, 0, 2, 3, 4)
+	assertIndexQuery(t, index, StatsQuery{UserId: "user2", From: dec}, 1)
+	assertIndexQuery(t, index, StatsQuery{ChannelId: "c1", MessageTs: "1"}, 0, 4)
+	assertIndexQuery(t, index, StatsQuery{MessageTs: "1"}, 0, 4, 2)
+	assertIndexQuery(t, index, StatsQuery{From: feb.AddDate(0, 1, 0)})
+	assertIndexQuery(t, newEventIndex(), StatsQuery{From: jan, To: feb})
+}
+
+func assertIndexQuery(t *testing.T, index *eventIndex, query StatsQuery, positions ...int) {
+	expected := make([]SwearEvent, len(positions))
+	for i, position := range positions {
+		expected[i] = index.events[position]
+	}
+	actual := index.query(query)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected events %v for query %v, got %v", expected, query, actual)
+	}
+}
@@ -0,0 +1,101 @@
+package modswears
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Inflection replaces the ending of a lemma with each of its forms.
+// Diminutives are appended to the stem and inflected once more with the
+// inflection of their own ending. Inflection with empty ending applies to
+// lemmas ending with a consonant.
+type inflection struct {
+	ending      string
+	forms       []string
+	diminutives []string
+}
+
+const vowels = "aeiouyąęó"
+
+// Simplified Polish declension and conjugation, enough to cover case forms
+// and common diminutives of words found in the swear dictionary.
+var inflections = []inflection{
+	// feminine nouns: dupa, cipa
+	{"a", []string{"a", "y", "ie", "ę", "ą", "o", "", "om", "ami", "ach"}, []string{"ka", "cia", "eczka"}},
+	{"ta", []string{"ta", "ty", "cie", "tę", "tą", "to", "t", "tom", "tami", "tach"}, []string{"tka"}},
+	{"da", []string{"da", "dy", "dzie", "dę", "dą", "do", "d", "dom", "dami", "dach"}, []string{"dka", "deczka"}},
+	{"ra", []string{"ra", "ry", "rze", "rę", "rą", "ro", "r", "rom", "rami", "rach"}, []string{"rka"}},
+	{"ła", []string{"ła", "ły", "le", "łę", "łą", "ło", "ł", "łom", "łami", "łach"}, []string{"łka"}},
+	{"wa", []string{"wa", "wy", "wie", "wę", "wą", "wo", "ew", "wom", "wami", "wach"}, []string{"wka"}},
+	{"ka", []string{"ka", "ki", "ce", "kę", "ką", "ko", "ek", "kom", "kami", "kach"}, nil},
+	{"ga", []string{"ga", "gi", "dze", "gę", "gą", "go", "g", "gom", "gami", "gach"}, nil},
+	{"cha", []string{"cha", "chy", "sze", "chę", "chą", "cho", "ch", "chom", "chami", "chach"}, nil},
+	{"ia", []string{"ia", "i", "ię", "ią", "io", "iom", "iami", "iach"}, nil},
+	// masculine nouns: chuj, kutas
+	{"", []string{"", "a", "u", "owi", "em", "ie", "y", "e", "ów", "om", "ami", "ach"}, []string{"ek"}},
+	{"ek", []string{"ek", "ka", "ku", "kowi", "kiem", "ki", "ków", "kom", "kami", "kach"}, nil},
+	// neuter nouns: gówno
+	{"o", []string{"o", "a", "u", "em", "ie", "", "om", "ami", "ach"}, []string{"ko", "eczko"}},
+	{"ko", []string{"ko", "ka", "ku", "kiem", "ki", "ek", "kom", "kami", "kach"}, nil},
+	// verbs: srać, robić
+	{"ać", []string{"ać", "am", "asz", "a", "amy", "acie", "ają", "aj", "ajcie", "ał", "ała", "ało", "ali", "ały", "ający", "ająca", "ające", "ając", "any", "ana"}, nil},
+	{"ić", []string{"ić", "ię", "isz", "i", "imy", "icie", "ią", "ij", "ijcie", "ił", "iła", "iło", "ili", "iły", "iący", "iąca", "iące", "iąc", "iony", "iona"}, nil},
+}
+
+// Returns all forms of the lemma, starting with the lemma itself, or false
+// if no inflection applies to it.
+func inflect(lemma string) ([]string, bool) {
+	stem, found := findInflection(lemma)
+	if found == nil {
+		return nil, false
+	}
+	forms := []string{}
+	seen := map[string]bool{}
+	add := func(form string) {
+		if !seen[form] {
+			seen[form] = true
+			forms = append(forms, form)
+		}
+	}
+	add(lemma)
+	for _, form := range found.forms {
+		add(stem + form)
+	}
+	for _, diminutive := range found.diminutives {
+		diminutiveStem, diminutiveFound := findInflection(stem + diminutive)
+		if diminutiveFound == nil {
+			continue
+		}
+		for _, form := range diminutiveFound.forms {
+			add(diminutiveStem + form)
+		}
+	}
+	return forms, true
+}
+
+// Returns the inflection with the longest ending matching the lemma,
+// together with the lemma stem.
+func findInflection(lemma string) (string, *inflection) {
+	var found *inflection
+	for i := range inflections {
+		current := &inflections[i]
+		if !strings.HasSuffix(lemma, current.ending) || len(lemma) <= len(current.ending) {
+			continue
+		}
+		if current.ending == "" && !endsWithConsonant(lemma) {
+			continue
+		}
+		if found == nil || len(current.ending) > len(found.ending) {
+			found = current
+		}
+	}
+	if found == nil {
+		return "", nil
+	}
+	return strings.TrimSuffix(lemma, found.ending), found
+}
+
+func endsWithConsonant(word string) bool {
+	last, _ := utf8.DecodeLastRuneInString(word)
+	return last != utf8.RuneError && !strings.ContainsRune(vowels, last)
+}
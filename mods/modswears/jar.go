@@ -0,0 +1,125 @@
+package modswears
+
+import (
+	"../../utils"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	JarFileReadErr       = 61
+	JarSaveErr           = 62
+	NotAdminErr          = 63
+	InvalidJarPaymentErr = 64
+)
+
+// Payments into the swear jar. Fines are not kept, they are computed from
+// swear events with prices from config, so changed prices apply to all
+// swears, the ones before the jar was introduced included.
+type SwearJar struct {
+	mutex    sync.Mutex
+	Payments []*JarPayment
+}
+
+// Payment of a user recorded by an admin.
+type JarPayment struct {
+	UserId     string
+	Amount     int
+	RecordedBy string
+	Time       time.Time
+}
+
+// Fines and payments of a user or of everyone.
+type JarBalance struct {
+	Fines int
+	Paid  int
+}
+
+func (balance JarBalance) Debt() int {
+	return balance.Fines - balance.Paid
+}
+
+func newSwearJar() *SwearJar {
+	return &SwearJar{
+		Payments: []*JarPayment{},
+	}
+}
+
+func (mod *ModSwears) LoadJar() int {
+	jar := newSwearJar()
+	err := utils.JsonFromFileCreate(mod.jarFileName, jar)
+	if err != nil {
+		log.Printf("ModSwears: Cannot read swear jar from file '%s'\n", mod.jarFileName)
+		return JarFileReadErr
+	}
+	mod.jar = jar
+	return Success
+}
+
+// GetJarBalance returns fines and payments of the user, or of all users
+// if no user is given.
+func (mod *ModSwears) GetJarBalance(userId string) (JarBalance, int) {
+	events, err := mod.stats.Query(StatsQuery{UserId: userId})
+	if err != Success {
+		return JarBalance{}, err
+	}
+	balance := JarBalance{}
+	for _, event := range events {
+		balance.Fines += mod.swearPrice(event.Category) * event.Count
+	}
+	mod.jar.mutex.Lock()
+	defer mod.jar.mutex.Unlock()
+	for _, payment := range mod.jar.Payments {
+		if userId == "" || payment.UserId == userId {
+			balance.Paid += payment.Amount
+		}
+	}
+	return balance, Success
+}
+
+// AddJarPayment records payment of the user. The payment is kept only if
+// it is saved to the jar file. Payments must be positive.
+func (mod *ModSwears) AddJarPayment(userId string, amount int, recordedBy string, now time.Time) int {
+	if amount <= 0 {
+		log.Printf("ModSwears: invalid payment of %d from '%s'\n", amount, userId)
+		return InvalidJarPaymentErr
+	}
+	mod.jar.mutex.Lock()
+	defer mod.jar.mutex.Unlock()
+	payment := &JarPayment{
+		UserId:     userId,
+		Amount:     amount,
+		RecordedBy: recordedBy,
+		Time:       now,
+	}
+	mod.jar.Payments = append(mod.jar.Payments, payment)
+	err := writeJar(mod.jarFileName, mod.jar)
+	if err != Success {
+		mod.jar.Payments = mod.jar.Payments[:len(mod.jar.Payments)-1]
+	}
+	return err
+}
+
+// Returns price of a swear of the category. Swears imported from monthly
+// stats have no category and cost the default price.
+func (mod *ModSwears) swearPrice(category string) int {
+	if price, ok := mod.config.JarCategoryPrices[category]; ok {
+		return price
+	}
+	return mod.config.JarSwearPrice
+}
+
+// Must be called with mutex of the jar held.
+func writeJar(fileName string, jar *SwearJar) int {
+	bytes, err := json.MarshalIndent(jar, "", "    ")
+	if err == nil {
+		err = utils.WriteFileAtomic(fileName, bytes)
+	}
+	if err != nil {
+		log.Printf("ModSwears: Cannot write swear jar to file '%s'\n", fileName)
+		return JarSaveErr
+	}
+	return Success
+}
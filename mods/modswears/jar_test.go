@@ -0,0 +1,97 @@
+package modswears
+
+import (
+	"../../mods"
+	"../../utils"
+	"github.com/nlopes/slack"
+	"os"
+	"testing"
+)
+
+func TestJarBalance(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+	defer os.Remove(tmpFilePath + ".jar")
+
+	mod := createStats(t, tmpFilePath)
+	mod.jarFileName = tmpFilePath + ".jar"
+	assertLoadJar(t, mod)
+	assertAddSwearCount(t, mod, 1, 2016, "user1", 3)
+	assertAddSwears(t, mod, 2, 2016, "user1", "a", "b")
+	assertAddSwears(t, mod, 2, 2016, "user2", "c")
+	mod.config.JarCategoryPrices["vulgar"] = 4
+
+	assertJarBalance(t, mod, "user1", JarBalance{Fines: 11})
+	assertAddJarPayment(t, mod, "user1", 5)
+	assertAddJarPayment(t, mod, "user2", 1)
+	assertAddJarPayment(t, mod, "user1", 2)
+	assertJarBalance(t, mod, "user1", JarBalance{Fines: 11, Paid: 7})
+	assertJarBalance(t, mod, "", JarBalance{Fines: 15, Paid: 8})
+
+	assertLoadJar(t, mod)
+	assertJarBalance(t, mod, "user2", JarBalance{Fines: 4, Paid: 1})
+	payment := mod.jar.Payments[2]
+	if payment.UserId != "user1" || payment.Amount != 2 || payment.RecordedBy != "admin1" {
+		t.Fatalf("Expected payment to be saved with its admin, got %#v", payment)
+	}
+}
+
+func TestAddInvalidJarPayment(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+	defer os.Remove(tmpFilePath + ".jar")
+
+	mod := createStats(t, tmpFilePath)
+	mod.jarFileName = tmpFilePath + ".jar"
+	assertLoadJar(t, mod)
+	for _, amount := range []int{0, -5} {
+		err := mod.AddJarPayment("user1", amount, "admin1", utils.TimeClock.Now())
+		if err != InvalidJarPaymentErr {
+			t.Fatalf("Expected error %d when paying %d, got %v", InvalidJarPaymentErr, amount, err)
+		}
+	}
+	mod.state = mods.NewState(nil, make(chan mods.Response))
+	mod.state.Users().SetUsers([]slack.User{slack.User{ID: "admin1", IsAdmin: true}})
+	if actual := mod.addJarPayment("admin1", "user1", "0"); actual != mod.config.OnInvalidJarPaymentErr {
+		t.Fatalf("Expected invalid payment response, got '%s'", actual)
+	}
+	assertLoadJar(t, mod)
+	assertJarBalance(t, mod, "user1", JarBalance{})
+	if len(mod.jar.Payments) != 0 {
+		t.Fatalf("Expected no payments to be saved, got %d", len(mod.jar.Payments))
+	}
+}
+
+func TestFormatJarBalance(t *testing.T) {
+	config := NewModSwearsConfig()
+	balance := JarBalance{Fines: 11, Paid: 7}
+	actual := formatJarBalance(config, "{user}: {debt} = {fines} - {paid}", balance, map[string]string{"user": "john"})
+	expected := "john: $4 = $11 - $7"
+	if actual != expected {
+		t.Fatalf("Expected balance '%s', got '%s'", expected, actual)
+	}
+}
+
+func assertLoadJar(t *testing.T, mod *ModSwears) {
+	err := mod.LoadJar()
+	if err != Success {
+		t.Fatalf("Expected to load swear jar without errors, got %v", err)
+	}
+}
+
+func assertAddJarPayment(t *testing.T, mod *ModSwears, userId string, amount int) {
+	err := mod.AddJarPayment(userId, amount, "admin1", utils.TimeClock.Now())
+	if err != Success {
+		t.Fatalf("Expected no error when adding payment but got %v", err)
+	}
+}
+
+func assertJarBalance(t *testing.T, mod *ModSwears, userId string, expected JarBalance) {
+	actual, err := mod.GetJarBalance(userId)
+	if err != Success {
+		t.Fatalf("Expected no error when getting balance but got %v", err)
+	}
+	if actual != expected {
+		t.Fatalf("Expected balance %#v of '%s', got %#v", expected, userId, actual)
+	}
+}
@@ -0,0 +1,207 @@
+package modswears
+
+import (
+	"../../kvstore"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	kvEventsPrefix     = "events/"
+	kvMonthStatsPrefix = "swears/"
+	kvEventTimeFormat  = "20060102T150405.000000000Z"
+)
+
+// Events kept in the key-value store, keyed by time and sequence number,
+// so they are scanned in the order they happened. Events of a message are
+// written as one batch, so a message is never counted partially. Events
+// are decoded once on load and queried from the index.
+type kvStatsStore struct {
+	mutex    sync.Mutex
+	fileName string
+	store    *kvstore.Store
+	lastSeq  int64
+	index    *eventIndex
+}
+
+// Monthly stats of a user kept per channel and word before events were
+// kept, imported as synthetic events on load.
+type kvSwearStats struct {
+	Count      int
+	Score      int
+	Categories map[string]int `json:",omitempty"`
+}
+
+func newKvStatsStore(fileName string) *kvStatsStore {
+	return &kvStatsStore{
+		fileName: fileName,
+	}
+}
+
+func (s *kvStatsStore) Load() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	store, err := kvstore.Open(s.fileName)
+	if err != kvstore.Success {
+		log.Printf("ModSwears: Cannot open stats store '%s'\n", s.fileName)
+		return StatsFileReadErr
+	}
+	if s.store != nil {
+		s.store.Close()
+	}
+	s.store = store
+	s.lastSeq = 0
+	s.index = newEventIndex()
+	s.store.Scan(kvEventsPrefix, func(key string, value string) {
+		if seq, ok := parseKvEventSeq(key); ok && seq > s.lastSeq {
+			s.lastSeq = seq
+		}
+		event := SwearEvent{}
+		if err := json.Unmarshal([]byte(value), &event); err != nil {
+			log.Printf("ModSwears: Skipping broken event of key '%s'\n", key)
+			return
+		}
+		s.index.add(event)
+	})
+	return s.importMonthStats()
+}
+
+func (s *kvStatsStore) Add(events []SwearEvent) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.store == nil {
+		return StatsSaveErr
+	}
+	batch := kvstore.NewBatch()
+	s.setEvents(batch, events)
+	if s.store.Write(batch) != kvstore.Success {
+		log.Printf("ModSwears: Cannot write to stats store '%s'\n", s.fileName)
+		s.lastSeq -= int64(len(events))
+		return StatsSaveErr
+	}
+	s.index.add(events...)
+	return Success
+}
+
+func (s *kvStatsStore) Query(query StatsQuery) ([]SwearEvent, int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.store == nil {
+		return nil, StatsFileReadErr
+	}
+	return s.index.query(query), Success
+}
+
+// Every event is written when added, nothing is left to flush.
+func (s *kvStatsStore) Flush() int {
+	return Success
+}
+
+func (s *kvStatsStore) Close() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.store == nil {
+		return Success
+	}
+	err := s.store.Close()
+	s.store = nil
+	if err != kvstore.Success {
+		return StatsSaveErr
+	}
+	return Success
+}
+
+func (s *kvStatsStore) isEmpty() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.store == nil || s.store.Len() == 0
+}
+
+// Adds events to the batch under new sequence numbers. Must be called
+// with mutex held.
+func (s *kvStatsStore) setEvents(batch *kvstore.Batch, events []SwearEvent) {
+	for _, event := range events {
+		s.lastSeq++
+		bytes, _ := json.Marshal(event)
+		batch.Set[getKvEventKey(event.Time, s.lastSeq)] = string(bytes)
+	}
+}
+
+// Replaces monthly stats with synthetic events in a single batch. Must be
+// called with mutex held.
+func (s *kvStatsStore) importMonthStats() int {
+	batch := kvstore.NewBatch()
+	events := []SwearEvent{}
+	s.store.Scan(kvMonthStatsPrefix, func(key string, value string) {
+		batch.Delete = append(batch.Delete, key)
+		month, year, userId, channelId, word, ok := parseKvMonthStatsKey(key)
+		stats := kvSwearStats{}
+		if !ok || json.Unmarshal([]byte(value), &stats) != nil {
+			log.Printf("ModSwears: Skipping broken stats of key '%s'\n", key)
+			return
+		}
+		monthStats := &MonthStats{
+			Month: month,
+			Year:  year,
+			Users: []*UserStats{
+				&UserStats{
+					UserId:     userId,
+					SwearCount: stats.Count,
+					Score:      stats.Score,
+					Categories: stats.Categories,
+				},
+			},
+		}
+		for _, event := range monthStatsEvents(monthStats) {
+			event.ChannelId = channelId
+			event.Rule = word
+			events = append(events, event)
+		}
+	})
+	if len(batch.Delete) == 0 {
+		return Success
+	}
+	s.setEvents(batch, events)
+	if s.store.Write(batch) != kvstore.Success {
+		log.Printf("ModSwears: Cannot import monthly stats into stats store '%s'\n", s.fileName)
+		return StatsFileReadErr
+	}
+	s.index.add(events...)
+	return Success
+}
+
+func getKvEventKey(eventTime time.Time, seq int64) string {
+	return fmt.Sprintf("%s%s/%012d", kvEventsPrefix, eventTime.UTC().Format(kvEventTimeFormat), seq)
+}
+
+func parseKvEventSeq(key string) (int64, bool) {
+	index := strings.LastIndex(key, "/")
+	seq, err := strconv.ParseInt(key[index+1:], 10, 64)
+	return seq, err == nil
+}
+
+func parseKvMonthStatsKey(key string) (int, int, string, string, string, bool) {
+	parts := strings.Split(strings.TrimPrefix(key, kvMonthStatsPrefix), "/")
+	if len(parts) != 4 {
+		return 0, 0, "", "", "", false
+	}
+	var month, year int
+	if _, err := fmt.Sscanf(parts[0], "%d-%d", &year, &month); err != nil {
+		return 0, 0, "", "", "", false
+	}
+	unescaped := make([]string, 3)
+	for i, part := range parts[1:] {
+		value, err := url.QueryUnescape(part)
+		if err != nil {
+			return 0, 0, "", "", "", false
+		}
+		unescaped[i] = value
+	}
+	return month, year, unescaped[0], unescaped[1], unescaped[2], true
+}
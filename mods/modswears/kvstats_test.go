@@ -0,0 +1,126 @@
+package modswears
+
+import (
+	"../../kvstore"
+	"../../utils"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestKvStatsRank(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer os.Remove(tmpFilePath)
+
+	mod := createKvStats(t, tmpFilePath)
+	assertAddSwearCount(t, mod, 1, 2016, "user1", 3)
+	assertAddSwearCount(t, mod, 1, 2016, "user2", 4)
+	assertAddSwearCount(t, mod, 2, 2016, "user1", 2)
+
+	expected := []*UserStats{
+		&UserStats{UserId: "user2", SwearCount: 4, Score: 4},
+		&UserStats{UserId: "user1", SwearCount: 3, Score: 3},
+	}
+	assertMonthlyRank(t, mod, 1, 2016, expected)
+
+	mod.Close()
+	mod = createKvStats(t, tmpFilePath)
+	expected = []*UserStats{
+		&UserStats{UserId: "user1", SwearCount: 5, Score: 5},
+		&UserStats{UserId: "user2", SwearCount: 4, Score: 4},
+	}
+	assertTotalRank(t, mod, expected)
+}
+
+func TestKvStatsQuery(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer os.Remove(tmpFilePath)
+
+	mod := createKvStats(t, tmpFilePath)
+	mod.getRules().ruleInfos.Rules["s/l"] = &RuleInfo{Category: "slur"}
+	assertAddSwears(t, mod, 1, 2016, "user1", "s/l", "s/l", "v")
+	assertAddSwears(t, mod, 1, 2016, "user2", "v")
+	assertAddSwears(t, mod, 3, 2016, "user1", "v")
+	mod.stats.Add([]SwearEvent{
+		SwearEvent{
+			UserId:    "user2",
+			ChannelId: "channel2",
+			Time:      utils.NewLocalDate(2016, 3, 5),
+			Rule:      "v",
+			Category:  "vulgar",
+			Count:     1,
+			Score:     2,
+		},
+	})
+
+	assertStatsQuery(t, mod, StatsQuery{Rule: "s/l"}, []*UserStats{
+		&UserStats{UserId: "user1", SwearCount: 2, Score: 10, Categories: map[string]int{"slur": 2}},
+	})
+	assertStatsQuery(t, mod, StatsQuery{ChannelId: "channel2"}, []*UserStats{
+		&UserStats{UserId: "user2", SwearCount: 1, Score: 2, Categories: map[string]int{"vulgar": 1}},
+	})
+	query := monthQuery(2, 2016)
+	query.From = monthQuery(1, 2016).From
+	query.UserId = "user1"
+	assertStatsQuery(t, mod, query, []*UserStats{
+		&UserStats{UserId: "user1", SwearCount: 3, Score: 12, Categories: map[string]int{"slur": 2, "vulgar": 1}},
+	})
+}
+
+func TestKvStatsEventOrder(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer os.Remove(tmpFilePath)
+
+	mod := createKvStats(t, tmpFilePath)
+	assertAddSwears(t, mod, 3, 2016, "user1", "v")
+	assertAddSwears(t, mod, 1, 2016, "user2", "v")
+	mod.Close()
+
+	mod = createKvStats(t, tmpFilePath)
+	assertAddSwears(t, mod, 1, 2016, "user3", "v")
+	events, _ := mod.stats.Query(StatsQuery{})
+	if len(events) != 3 || events[0].UserId != "user2" || events[1].UserId != "user3" {
+		t.Fatalf("Expected events ordered by time, got %#v", events)
+	}
+}
+
+func TestKvStatsImportMonthStats(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer os.Remove(tmpFilePath)
+
+	store, _ := kvstore.Open(tmpFilePath)
+	store.Put("swears/2016-01/user1/channel1/s%2Fl", `{"Count":2,"Score":10,"Categories":{"slur":2}}`)
+	store.Put("swears/2016-01/user2//", `{"Count":1,"Score":1}`)
+	store.Close()
+
+	mod := createKvStats(t, tmpFilePath)
+	expected := []*UserStats{
+		&UserStats{UserId: "user1", SwearCount: 2, Score: 10, Categories: map[string]int{"slur": 2}},
+	}
+	assertStatsQuery(t, mod, StatsQuery{Rule: "s/l", ChannelId: "channel1"}, expected)
+	mod.Close()
+
+	mod = createKvStats(t, tmpFilePath)
+	expected = append(expected, &UserStats{UserId: "user2", SwearCount: 1, Score: 1})
+	assertMonthlyRank(t, mod, 1, 2016, expected)
+}
+
+func createKvStats(t *testing.T, tmpFilePath string) *ModSwears {
+	mod := NewModSwears()
+	mod.stats = newKvStatsStore(tmpFilePath)
+	err := mod.LoadStats()
+	if err != Success {
+		t.Fatalf("Expected to load stats without errors, got %v", err)
+	}
+	return mod
+}
+
+func assertStatsQuery(t *testing.T, mod *ModSwears, query StatsQuery, expected []*UserStats) {
+	actual, err := mod.GetRank(query)
+	if err != Success {
+		t.Fatalf("Expected no error when querying stats but got %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected stats %v, got %v", expected, actual)
+	}
+}
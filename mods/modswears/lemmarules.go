@@ -0,0 +1,81 @@
+package modswears
+
+import (
+	"log"
+	"strings"
+)
+
+const (
+	UnknownLemmaErr    = 30
+	LemmaFormRemoveErr = 20
+)
+
+const lemmaRuleMarker = "+"
+
+// Lemma rule is written in the dictionary as +lemma and adds all forms of
+// the lemma to the dictionary. Forms are added as regular entries, so they
+// are checked for conflicts with other rules like any other word.
+func isLemmaRule(rule string) bool {
+	return len(rule) > len(lemmaRuleMarker) && strings.HasPrefix(rule, lemmaRuleMarker)
+}
+
+// Adds forms of the lemma to the dictionary. If any form conflicts with
+// an existing rule, forms added so far are removed again.
+func (rules *swearRules) addLemmaRule(rule string) int {
+	if _, ok := rules.lemmas[rule]; ok {
+		log.Printf("ModSwears: add rule: lemma rule '%s' already exists\n", rule)
+		return AddRuleConflictErr
+	}
+	forms, ok := inflect(strings.TrimPrefix(rule, lemmaRuleMarker))
+	if !ok {
+		log.Printf("ModSwears: add rule: no inflection for lemma rule '%s'\n", rule)
+		return UnknownLemmaErr
+	}
+	added := []string{}
+	for _, form := range forms {
+		if existing, ok := rules.dict.FindEntry(form); ok && rules.lemmaForms[existing] == rule {
+			continue
+		}
+		form, err := rules.addEntry(form)
+		if err != Success {
+			log.Printf("ModSwears: add rule: form '%s' of lemma rule '%s' conflicts\n", form, rule)
+			for _, addedForm := range added {
+				rules.dict.RemoveEntry(addedForm)
+				delete(rules.lemmaForms, addedForm)
+			}
+			return err
+		}
+		added = append(added, form)
+		rules.lemmaForms[form] = rule
+	}
+	rules.lemmas[rule] = added
+	return Success
+}
+
+func (rules *swearRules) removeLemmaRule(rule string) bool {
+	forms, ok := rules.lemmas[rule]
+	if !ok {
+		return false
+	}
+	for _, form := range forms {
+		rules.dict.RemoveEntry(form)
+		delete(rules.lemmaForms, form)
+	}
+	delete(rules.lemmas, rule)
+	return true
+}
+
+// Returns the rule which added the dictionary entry, which is the lemma
+// rule for lemma forms and the entry itself otherwise.
+func (rules *swearRules) ruleOf(entry string) string {
+	if rule, ok := rules.lemmaForms[entry]; ok {
+		return rule
+	}
+	return entry
+}
+
+// LemmaForms returns forms added to the dictionary by the lemma rule.
+func (mod *ModSwears) LemmaForms(rule string) ([]string, bool) {
+	forms, ok := mod.getRules().lemmas[rule]
+	return forms, ok
+}
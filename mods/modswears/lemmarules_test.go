@@ -0,0 +1,89 @@
+package modswears
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestAddLemmaRule(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := createSwears(t, tmpFileName)
+	assertAddRule(t, mod, "+Dupa")
+	assertFindSwears(t, mod, "dupie dupą dupeczka dupek dup", []string{"dupie", "dupą", "dupeczka", "dupek", "dup"})
+	assertWhichRule(t, mod, "dupeczce", "+dupa", true)
+	assertListRules(t, mod, "", []string{"+dupa", "a", "abb*", "abcd"})
+	assertListRules(t, mod, "+du", []string{"+dupa"})
+	assertDictFile(t, tmpFileName, "a\nabcd\nabb*\n+dupa\n")
+
+	mod = createSwears(t, tmpFileName)
+	assertWhichRule(t, mod, "dupy", "+dupa", true)
+	assertAddRuleErr(t, mod, "+dupa", AddRuleConflictErr)
+	assertAddRuleErr(t, mod, "dupkami", AddRuleConflictErr)
+}
+
+func TestAddLemmaRuleConflict(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := createSwears(t, tmpFileName)
+	assertAddRuleErr(t, mod, "+abba", AddRuleConflictErr)
+	assertFindSwears(t, mod, "abby abbie abbę", []string{"abby", "abbie", "abbę"})
+	assertFindSwears(t, mod, "abo", []string{})
+	assertListRules(t, mod, "", []string{"a", "abb*", "abcd"})
+	assertDictFile(t, tmpFileName, "a\nabcd\nabb*\n")
+}
+
+func TestAddLemmaRuleUnknownLemmaErr(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := createSwears(t, tmpFileName)
+	assertAddRuleErr(t, mod, "+kakadu", UnknownLemmaErr)
+	assertDictFile(t, tmpFileName, "a\nabcd\nabb*\n")
+}
+
+func TestRemoveLemmaRule(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := createSwears(t, tmpFileName)
+	assertAddRule(t, mod, "+srać")
+	assertAddRule(t, mod, "+cipa")
+	assertRemoveRuleErr(t, mod, "srający", LemmaFormRemoveErr)
+	assertFindSwears(t, mod, "sram cipie", []string{"sram", "cipie"})
+	expected := "This word is a form of rule '+srać', remove the whole rule instead!"
+	if actual := mod.removeRule("sram"); actual != expected {
+		t.Fatalf("Expected response '%s', got '%s'", expected, actual)
+	}
+	assertRemoveRule(t, mod, "+srać")
+	assertFindSwears(t, mod, "sram cipie", []string{"cipie"})
+	assertRemoveRule(t, mod, "+cipa")
+	assertFindSwears(t, mod, "sram cipie", []string{})
+	assertListRules(t, mod, "", []string{"a", "abb*", "abcd"})
+	assertDictFile(t, tmpFileName, "a\nabcd\nabb*\n")
+	assertAddRule(t, mod, "cipa")
+}
+
+func TestInflect(t *testing.T) {
+	assertInflect(t, "dupa", []string{"dupa", "dupy", "dupie", "dupę", "dupą", "dupo", "dup", "dupom", "dupami", "dupach",
+		"dupka", "dupki", "dupce", "dupkę", "dupką", "dupko", "dupek", "dupkom", "dupkami", "dupkach",
+		"dupcia", "dupci", "dupcię", "dupcią", "dupcio", "dupciom", "dupciami", "dupciach",
+		"dupeczka", "dupeczki", "dupeczce", "dupeczkę", "dupeczką", "dupeczko", "dupeczek", "dupeczkom", "dupeczkami", "dupeczkach"})
+	assertInflect(t, "pizda", []string{"pizda", "pizdy", "pizdzie", "pizdę", "pizdą", "pizdo", "pizd", "pizdom", "pizdami", "pizdach",
+		"pizdka", "pizdki", "pizdce", "pizdkę", "pizdką", "pizdko", "pizdek", "pizdkom", "pizdkami", "pizdkach",
+		"pizdeczka", "pizdeczki", "pizdeczce", "pizdeczkę", "pizdeczką", "pizdeczko", "pizdeczek", "pizdeczkom", "pizdeczkami", "pizdeczkach"})
+	assertInflect(t, "kutas", []string{"kutas", "kutasa", "kutasu", "kutasowi", "kutasem", "kutasie", "kutasy", "kutase", "kutasów", "kutasom", "kutasami", "kutasach",
+		"kutasek", "kutaska", "kutasku", "kutaskowi", "kutaskiem", "kutaski", "kutasków", "kutaskom", "kutaskami", "kutaskach"})
+	assertInflect(t, "kakadu", nil)
+	assertInflect(t, "a", nil)
+}
+
+func assertInflect(t *testing.T, lemma string, expected []string) {
+	actual, ok := inflect(lemma)
+	if ok != (expected != nil) || !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected forms %#v of '%s', got %#v", expected, lemma, actual)
+	}
+}
@@ -1,7 +1,6 @@
 package modswears
 
 import (
-	"../../dictmatch"
 	"../../mods"
 	"../../settings"
 	"../../utils"
@@ -12,38 +11,90 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
-	Success        = 0
-	ConfigFileName = "config.json"
-	DictFileName   = "swears.txt"
-	StatsFileName  = "stats.json"
+	Success             = 0
+	ConfigFileName      = "config.json"
+	DictFileName        = "swears.txt"
+	StatsFileName       = "stats.json"
+	StatsLogFileName    = "stats.log"
+	StatsDbFileName     = "stats.db"
+	RuleInfoFileName    = "rules.json"
+	JarFileName         = "jar.json"
+	CorrectionsFileName = "corrections.json"
 )
 
 const (
 	SettingSwearNotify = "ModSwears.SwearNotify"
 )
 
+const (
+	UserNameDisplay = "display"
+	UserNameReal    = "real"
+	UserNameLogin   = "login"
+)
+
 type ModSwears struct {
 	state               mods.State
-	dict                *dictmatch.Dict
+	rules               atomic.Value
+	rulesMutex          sync.Mutex
+	dictModTime         time.Time
+	stopWatch           chan struct{}
+	stats               StatsStore
 	addRuleRegex        *regexp.Regexp
+	addExceptionRegex   *regexp.Regexp
+	removeRuleRegex     *regexp.Regexp
+	listRulesRegex      *regexp.Regexp
+	whichRuleRegex      *regexp.Regexp
+	reloadRulesRegex    *regexp.Regexp
 	currMonthRankRegex  *regexp.Regexp
 	prevMonthRankRegex  *regexp.Regexp
 	totalRankRegex      *regexp.Regexp
+	channelRankRegex    *regexp.Regexp
+	weekRankRegex       *regexp.Regexp
+	yearRankRegex       *regexp.Regexp
+	monthRankRegex      *regexp.Regexp
+	dateRangeRankRegex  *regexp.Regexp
+	topSwearsRegex      *regexp.Regexp
+	favSwearsRegex      *regexp.Regexp
+	myStatsRegex        *regexp.Regexp
+	userStatsRegex      *regexp.Regexp
+	migrateStatsRegex   *regexp.Regexp
 	swearNotifyOnRegex  *regexp.Regexp
 	swearNotifyOffRegex *regexp.Regexp
+	announceOnRegex     *regexp.Regexp
+	announceOffRegex    *regexp.Regexp
+	myDebtRegex         *regexp.Regexp
+	jarTotalRegex       *regexp.Regexp
+	paidRegex           *regexp.Regexp
+	pardonRegex         *regexp.Regexp
+	resetStatsRegex     *regexp.Regexp
+	mergeUserRegex      *regexp.Regexp
+	correctionsRegex    *regexp.Regexp
 	config              *ModSwearsConfig
 	dictFileName        string
+	ruleInfoFileName    string
 	statsFileName       string
+	statsLogFileName    string
+	jarFileName         string
+	jar                 *SwearJar
+	correctionsFileName string
+	corrections         *StatsCorrections
+	missedUsers         map[string]time.Time
+	missedUsersMutex    sync.Mutex
 }
 
 func NewModSwears() *ModSwears {
-	return &ModSwears{
-		dict:   dictmatch.NewDict(),
-		config: NewModSwearsConfig(),
+	mod := &ModSwears{
+		config:      NewModSwearsConfig(),
+		missedUsers: map[string]time.Time{},
 	}
+	mod.setRules(newSwearRules(mod.config, newRuleInfos()))
+	return mod
 }
 
 func (mod *ModSwears) Name() string {
@@ -56,6 +107,10 @@ func (mod *ModSwears) Init(state mods.State) bool {
 	mod.state = state
 	mod.dictFileName = mods.GetPath(mod, DictFileName)
 	mod.statsFileName = mods.GetPath(mod, StatsFileName)
+	mod.statsLogFileName = mods.GetPath(mod, StatsLogFileName)
+	mod.ruleInfoFileName = mods.GetPath(mod, RuleInfoFileName)
+	mod.jarFileName = mods.GetPath(mod, JarFileName)
+	mod.correctionsFileName = mods.GetPath(mod, CorrectionsFileName)
 	configFileName := mods.GetPath(mod, ConfigFileName)
 	err = utils.JsonFromFileCreate(configFileName, mod.config)
 	if err != nil {
@@ -67,6 +122,31 @@ func (mod *ModSwears) Init(state mods.State) bool {
 		log.Printf("ModSwears: cannot compile AddRuleRegex: %v\n", err)
 		return false
 	}
+	mod.addExceptionRegex, err = regexp.Compile(mod.config.AddExceptionRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile AddExceptionRegex: %v\n", err)
+		return false
+	}
+	mod.removeRuleRegex, err = regexp.Compile(mod.config.RemoveRuleRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile RemoveRuleRegex: %v\n", err)
+		return false
+	}
+	mod.listRulesRegex, err = regexp.Compile(mod.config.ListRulesRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile ListRulesRegex: %v\n", err)
+		return false
+	}
+	mod.whichRuleRegex, err = regexp.Compile(mod.config.WhichRuleRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile WhichRuleRegex: %v\n", err)
+		return false
+	}
+	mod.reloadRulesRegex, err = regexp.Compile(mod.config.ReloadRulesRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile ReloadRulesRegex: %v\n", err)
+		return false
+	}
 	mod.currMonthRankRegex, err = regexp.Compile(mod.config.CurrMonthRankRegex)
 	if err != nil {
 		log.Printf("ModSwears: cannot compile CurrMonthRankRegex: %v\n", err)
@@ -80,6 +160,56 @@ func (mod *ModSwears) Init(state mods.State) bool {
 	if err != nil {
 		log.Printf("ModSwears: cannot compile TotalRankRegex: %v\n", err)
 	}
+	mod.channelRankRegex, err = regexp.Compile(mod.config.ChannelRankRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile ChannelRankRegex: %v\n", err)
+		return false
+	}
+	mod.weekRankRegex, err = regexp.Compile(mod.config.WeekRankRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile WeekRankRegex: %v\n", err)
+		return false
+	}
+	mod.yearRankRegex, err = regexp.Compile(mod.config.YearRankRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile YearRankRegex: %v\n", err)
+		return false
+	}
+	mod.monthRankRegex, err = regexp.Compile(mod.config.MonthRankRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile MonthRankRegex: %v\n", err)
+		return false
+	}
+	mod.dateRangeRankRegex, err = regexp.Compile(mod.config.DateRangeRankRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile DateRangeRankRegex: %v\n", err)
+		return false
+	}
+	mod.topSwearsRegex, err = regexp.Compile(mod.config.TopSwearsRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile TopSwearsRegex: %v\n", err)
+		return false
+	}
+	mod.favSwearsRegex, err = regexp.Compile(mod.config.FavSwearsRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile FavSwearsRegex: %v\n", err)
+		return false
+	}
+	mod.myStatsRegex, err = regexp.Compile(mod.config.MyStatsRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile MyStatsRegex: %v\n", err)
+		return false
+	}
+	mod.userStatsRegex, err = regexp.Compile(mod.config.UserStatsRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile UserStatsRegex: %v\n", err)
+		return false
+	}
+	mod.migrateStatsRegex, err = regexp.Compile(mod.config.MigrateStatsRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile MigrateStatsRegex: %v\n", err)
+		return false
+	}
 	mod.swearNotifyOnRegex, err = regexp.Compile(mod.config.SwearNotifyOnRegex)
 	if err != nil {
 		log.Printf("ModSwears: cannot compile SwearNotifyOnRegex: %v\n", err)
@@ -90,11 +220,130 @@ func (mod *ModSwears) Init(state mods.State) bool {
 		log.Printf("ModSwears: cannot compile SwearNotifyOffRegex: %v\n", err)
 		return false
 	}
+	mod.announceOnRegex, err = regexp.Compile(mod.config.AnnounceOnRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile AnnounceOnRegex: %v\n", err)
+		return false
+	}
+	mod.announceOffRegex, err = regexp.Compile(mod.config.AnnounceOffRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile AnnounceOffRegex: %v\n", err)
+		return false
+	}
+	mod.myDebtRegex, err = regexp.Compile(mod.config.MyDebtRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile MyDebtRegex: %v\n", err)
+		return false
+	}
+	mod.jarTotalRegex, err = regexp.Compile(mod.config.JarTotalRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile JarTotalRegex: %v\n", err)
+		return false
+	}
+	mod.paidRegex, err = regexp.Compile(mod.config.PaidRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile PaidRegex: %v\n", err)
+		return false
+	}
+	mod.pardonRegex, err = regexp.Compile(mod.config.PardonRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile PardonRegex: %v\n", err)
+		return false
+	}
+	mod.resetStatsRegex, err = regexp.Compile(mod.config.ResetStatsRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile ResetStatsRegex: %v\n", err)
+		return false
+	}
+	mod.mergeUserRegex, err = regexp.Compile(mod.config.MergeUserRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile MergeUserRegex: %v\n", err)
+		return false
+	}
+	mod.correctionsRegex, err = regexp.Compile(mod.config.CorrectionsRegex)
+	if err != nil {
+		log.Printf("ModSwears: cannot compile CorrectionsRegex: %v\n", err)
+		return false
+	}
+	announceLocation, err := time.LoadLocation(mod.config.AnnounceTimeZone)
+	if err != nil {
+		log.Printf("ModSwears: unknown AnnounceTimeZone '%s'\n", mod.config.AnnounceTimeZone)
+		return false
+	}
+	if mod.config.RankSortBy != RankSortByScore && mod.config.RankSortBy != RankSortByCount {
+		log.Printf("ModSwears: unknown RankSortBy '%s'\n", mod.config.RankSortBy)
+		return false
+	}
+	if !isKnownCategory(mod.config.DefaultRuleCategory) {
+		log.Printf("ModSwears: unknown DefaultRuleCategory '%s'\n", mod.config.DefaultRuleCategory)
+		return false
+	}
+	for category := range mod.config.CategoryWeights {
+		if !isKnownCategory(category) {
+			log.Printf("ModSwears: unknown category '%s' in CategoryWeights\n", category)
+			return false
+		}
+	}
+	if mod.config.WatchDictFile && mod.config.WatchDictIntervalSec <= 0 {
+		log.Printf("ModSwears: invalid WatchDictIntervalSec %d\n", mod.config.WatchDictIntervalSec)
+		return false
+	}
+	if mod.config.StatsFlushIntervalSec <= 0 {
+		log.Printf("ModSwears: invalid StatsFlushIntervalSec %d\n", mod.config.StatsFlushIntervalSec)
+		return false
+	}
+	if mod.config.UserFetchRetrySec <= 0 {
+		log.Printf("ModSwears: invalid UserFetchRetrySec %d\n", mod.config.UserFetchRetrySec)
+		return false
+	}
+	if mod.config.UserNameSource != UserNameDisplay &&
+		mod.config.UserNameSource != UserNameReal &&
+		mod.config.UserNameSource != UserNameLogin {
+
+		log.Printf("ModSwears: unknown UserNameSource '%s'\n", mod.config.UserNameSource)
+		return false
+	}
+	if mod.config.StatsBackend != StatsBackendJson && mod.config.StatsBackend != StatsBackendKv {
+		log.Printf("ModSwears: unknown StatsBackend '%s'\n", mod.config.StatsBackend)
+		return false
+	}
+	mod.stats = newStatsStore(
+		mod.config.StatsBackend,
+		mod.statsFileName,
+		mod.statsLogFileName,
+		mods.GetPath(mod, StatsDbFileName),
+		mod.config.StatsCompactLogSize)
 	errnum = mod.LoadSwears()
 	if errnum != Success {
 		log.Println("ModSwears: loading swears dictionary failed.")
 		return false
 	}
+	errnum = mod.LoadRuleInfos()
+	if errnum != Success {
+		log.Println("ModSwears: loading rule infos failed.")
+		return false
+	}
+	errnum = mod.LoadStats()
+	if errnum != Success {
+		log.Println("ModSwears: loading stats failed.")
+		return false
+	}
+	errnum = mod.LoadJar()
+	if errnum != Success {
+		log.Println("ModSwears: loading swear jar failed.")
+		return false
+	}
+	errnum = mod.LoadCorrections()
+	if errnum != Success {
+		log.Println("ModSwears: loading stats corrections failed.")
+		return false
+	}
+	go mod.flushStatsPeriodically(time.Duration(mod.config.StatsFlushIntervalSec) * time.Second)
+	go mod.announceRankMonthly(announceLocation)
+	if mod.config.WatchDictFile {
+		mod.stopWatch = make(chan struct{})
+		go mod.watchDictFile(time.Duration(mod.config.WatchDictIntervalSec)*time.Second, mod.stopWatch)
+	}
 	return true
 }
 
@@ -103,25 +352,114 @@ func (mod *ModSwears) ProcessMention(
 	userId string,
 	channelId string) *mods.Response {
 
-	if mod.currMonthRankRegex.MatchString(message) {
-		return response(mod.getCurrMonthRank(), channelId)
+	ranks := mod.currMonthRankRegex.FindStringSubmatch(message)
+	if ranks != nil {
+		return response(mod.getCurrMonthRank(getRankChannelId(ranks, channelId)), channelId)
+	}
+	ranks = mod.prevMonthRankRegex.FindStringSubmatch(message)
+	if ranks != nil {
+		return response(mod.getPrevMonthRank(getRankChannelId(ranks, channelId)), channelId)
+	}
+	ranks = mod.totalRankRegex.FindStringSubmatch(message)
+	if ranks != nil {
+		return response(mod.getTotalRank(getRankChannelId(ranks, channelId)), channelId)
+	}
+	if mod.channelRankRegex.MatchString(message) {
+		return response(mod.getChannelRank(), channelId)
+	}
+	if mod.weekRankRegex.MatchString(message) {
+		return response(mod.getWeekRank(), channelId)
+	}
+	years := mod.yearRankRegex.FindStringSubmatch(message)
+	if years != nil {
+		return response(mod.getYearRank(years[1]), channelId)
+	}
+	months := mod.monthRankRegex.FindStringSubmatch(message)
+	if months != nil {
+		return response(mod.getRankByMonthName(months[1], months[2]), channelId)
 	}
-	if mod.prevMonthRankRegex.MatchString(message) {
-		return response(mod.getPrevMonthRank(), channelId)
+	dates := mod.dateRangeRankRegex.FindStringSubmatch(message)
+	if dates != nil {
+		return response(mod.getRankByDates(dates[1], dates[2]), channelId)
 	}
-	if mod.totalRankRegex.MatchString(message) {
-		return response(mod.getTotalRank(), channelId)
+	tops := mod.topSwearsRegex.FindStringSubmatch(message)
+	if tops != nil {
+		return response(mod.getTopSwears(tops[1], tops[2]), channelId)
+	}
+	if mod.favSwearsRegex.MatchString(message) {
+		return response(mod.getTopSwears("", userId), channelId)
+	}
+	if mod.myStatsRegex.MatchString(message) {
+		return response(mod.getUserReport(userId), channelId)
+	}
+	users := mod.userStatsRegex.FindStringSubmatch(message)
+	if users != nil {
+		return response(mod.getUserReport(users[1]), channelId)
+	}
+	if mod.myDebtRegex.MatchString(message) {
+		return response(mod.getDebt(userId), channelId)
+	}
+	if mod.jarTotalRegex.MatchString(message) {
+		return response(mod.getJarTotal(), channelId)
+	}
+	payments := mod.paidRegex.FindStringSubmatch(message)
+	if payments != nil {
+		return response(mod.addJarPayment(userId, payments[1], payments[2]), channelId)
+	}
+	pardons := mod.pardonRegex.FindStringSubmatch(message)
+	if pardons != nil {
+		return response(mod.pardonSwears(userId, pardons[1], pardons[2], pardons[3], pardons[4]), channelId)
+	}
+	resets := mod.resetStatsRegex.FindStringSubmatch(message)
+	if resets != nil {
+		return response(mod.resetStats(userId, resets[1]), channelId)
+	}
+	merges := mod.mergeUserRegex.FindStringSubmatch(message)
+	if merges != nil {
+		return response(mod.mergeUser(userId, merges[1], merges[2]), channelId)
+	}
+	corrections := mod.correctionsRegex.FindStringSubmatch(message)
+	if corrections != nil {
+		return response(mod.getCorrections(corrections[1]), channelId)
+	}
+	if mod.migrateStatsRegex.MatchString(message) {
+		return response(mod.migrateStats(userId), channelId)
 	}
 	rules := mod.addRuleRegex.FindAllStringSubmatch(message, 1)
 	if rules != nil {
 		return response(mod.addRule(rules[0][1]), channelId)
 	}
+	rules = mod.addExceptionRegex.FindAllStringSubmatch(message, 1)
+	if rules != nil {
+		return response(mod.addException(rules[0][1]), channelId)
+	}
+	rules = mod.removeRuleRegex.FindAllStringSubmatch(message, 1)
+	if rules != nil {
+		return response(mod.removeRule(rules[0][1]), channelId)
+	}
+	prefixes := mod.listRulesRegex.FindAllStringSubmatch(message, 1)
+	if prefixes != nil {
+		return response(mod.listRules(prefixes[0][1]), channelId)
+	}
+	words := mod.whichRuleRegex.FindAllStringSubmatch(message, 1)
+	if words != nil {
+		return response(mod.whichRule(words[0][1]), channelId)
+	}
+	if mod.reloadRulesRegex.MatchString(message) {
+		return response(mod.reloadRules(), channelId)
+	}
 	if mod.swearNotifyOnRegex.MatchString(message) {
 		return response(mod.setSwearNotify(userId, channelId, "on"), channelId)
 	}
 	if mod.swearNotifyOffRegex.MatchString(message) {
 		return response(mod.setSwearNotify(userId, channelId, "off"), channelId)
 	}
+	if mod.announceOnRegex.MatchString(message) {
+		return response(mod.setAnnounceRank(channelId, true), channelId)
+	}
+	if mod.announceOffRegex.MatchString(message) {
+		return response(mod.setAnnounceRank(channelId, false), channelId)
+	}
 	return nil
 }
 
@@ -130,10 +468,18 @@ func (mod *ModSwears) ProcessMessage(
 	userId string,
 	channelId string) *mods.Response {
 
-	swears := mod.FindSwears(message)
-	if len(swears) > 0 {
-		now := utils.TimeClock.Now()
-		err := mod.AddSwearCount(int(now.Month()), now.Year(), userId, len(swears))
+	return mod.ProcessMessageTs(message, userId, channelId, "")
+}
+
+func (mod *ModSwears) ProcessMessageTs(
+	message string,
+	userId string,
+	channelId string,
+	messageTs string) *mods.Response {
+
+	matches := mod.FindSwearMatches(message)
+	if len(matches) > 0 {
+		err := mod.AddSwears(utils.TimeClock.Now(), userId, channelId, messageTs, matches)
 		if err != Success {
 			return response(getErrMessage(err, mod.config), channelId)
 		}
@@ -142,6 +488,10 @@ func (mod *ModSwears) ProcessMessage(
 			channelId,
 			SettingSwearNotify)
 		if exist && swearNotify == "on" {
+			swears := make([]string, len(matches))
+			for i, match := range matches {
+				swears[i] = match.Text
+			}
 			responseMessage := formatSwearsResponse(
 				mod.config.OnSwearsFoundResponse,
 				mod.config.SwearFormat,
@@ -162,36 +512,336 @@ func response(message string, channelId string) *mods.Response {
 	}
 }
 
-func (mod *ModSwears) getCurrMonthRank() string {
+func (mod *ModSwears) getCurrMonthRank(rankChannelId string) string {
 	now := utils.TimeClock.Now()
 	month := int(now.Month())
 	year := now.Year()
-	return mod.getRankByMonth(month, year)
+	return mod.getRankByMonth(month, year, rankChannelId)
 }
 
-func (mod *ModSwears) getPrevMonthRank() string {
+func (mod *ModSwears) getPrevMonthRank(rankChannelId string) string {
 	prevMonth := utils.LastDayOfPrevMonth(utils.TimeClock.Now())
 	month := int(prevMonth.Month())
 	year := prevMonth.Year()
-	return mod.getRankByMonth(month, year)
+	return mod.getRankByMonth(month, year, rankChannelId)
+}
+
+func (mod *ModSwears) getTotalRank(rankChannelId string) string {
+	userStats, rankErr := mod.GetRank(StatsQuery{ChannelId: rankChannelId})
+	response := mod.prepareRank(userStats, rankErr)
+	if response != "" {
+		return response
+	}
+	return formatTotalRank(mod.config, rankChannelId, userStats)
+}
+
+func (mod *ModSwears) getRankByMonth(month int, year int, rankChannelId string) string {
+	return mod.getMonthRankByQuery(monthQuery(month, year), month, year, rankChannelId)
 }
 
-func (mod *ModSwears) getTotalRank() string {
-	userStats, rankErr := mod.GetTotalRank()
+// Returns rank of the month, which starts and ends as set in the query.
+func (mod *ModSwears) getMonthRankByQuery(
+	query StatsQuery,
+	month int,
+	year int,
+	rankChannelId string) string {
+
+	query.ChannelId = rankChannelId
+	userStats, rankErr := mod.GetRank(query)
 	response := mod.prepareRank(userStats, rankErr)
 	if response != "" {
 		return response
 	}
-	return formatTotalRank(mod.config, userStats)
+	return formatMonthlyRank(mod.config, month, year, rankChannelId, userStats)
+}
+
+func (mod *ModSwears) getWeekRank() string {
+	query := weekQuery(utils.TimeClock.Now())
+	header := formatPeriodRankHeader(mod.config.WeeklyRankHeaderFormat, mod.config.DateFormat, query)
+	return mod.getRankByPeriod(query, header)
 }
 
-func (mod *ModSwears) getRankByMonth(month int, year int) string {
-	userStats, rankErr := mod.GetMonthlyRank(month, year)
+// Returns rank of the year, the current one if no year is given.
+func (mod *ModSwears) getYearRank(yearText string) string {
+	year := utils.TimeClock.Now().Year()
+	if yearText != "" {
+		year, _ = strconv.Atoi(yearText)
+	}
+	params := map[string]string{"year": strconv.Itoa(year)}
+	header := utils.ParamFormat(mod.config.YearlyRankHeaderFormat, params)
+	return mod.getRankByPeriod(yearQuery(year), header)
+}
+
+func (mod *ModSwears) getRankByMonthName(monthName string, yearText string) string {
+	month, ok := parseMonth(mod.config.MonthNames, monthName)
+	if !ok {
+		return getErrMessage(InvalidPeriodErr, mod.config)
+	}
+	year, _ := strconv.Atoi(yearText)
+	return mod.getRankByMonth(month, year, "")
+}
+
+func (mod *ModSwears) getRankByDates(first string, last string) string {
+	query, ok := parseDateRange(first, last)
+	if !ok {
+		return getErrMessage(InvalidPeriodErr, mod.config)
+	}
+	header := formatPeriodRankHeader(mod.config.PeriodRankHeaderFormat, mod.config.DateFormat, query)
+	return mod.getRankByPeriod(query, header)
+}
+
+func (mod *ModSwears) getRankByPeriod(query StatsQuery, header string) string {
+	userStats, rankErr := mod.GetRank(query)
 	response := mod.prepareRank(userStats, rankErr)
 	if response != "" {
 		return response
 	}
-	return formatMonthlyRank(mod.config, month, year, userStats)
+	rankLines := formatRankLines(mod.config, userStats)
+	return fmt.Sprintf("%s\n%s", header, rankLines)
+}
+
+func (mod *ModSwears) getChannelRank() string {
+	channelStats, rankErr := mod.GetChannelRank(StatsQuery{})
+	if rankErr != Success {
+		return getErrMessage(rankErr, mod.config)
+	}
+	if len(channelStats) == 0 {
+		return mod.config.OnEmptyRankResponse
+	}
+	return formatChannelRank(mod.config, channelStats)
+}
+
+// Returns most used swears of the period, which is curr, prev, a month
+// with a year, or empty or total for all time, of the user or of everyone
+// if no user is given.
+func (mod *ModSwears) getTopSwears(period string, topUserId string) string {
+	month, year := 0, 0
+	switch strings.ToLower(period) {
+	case "", "total":
+	case "curr":
+		now := utils.TimeClock.Now()
+		month, year = int(now.Month()), now.Year()
+	case "prev":
+		prevMonth := utils.LastDayOfPrevMonth(utils.TimeClock.Now())
+		month, year = int(prevMonth.Month()), prevMonth.Year()
+	default:
+		fields := strings.Fields(period)
+		var ok bool
+		month, ok = parseMonth(mod.config.MonthNames, fields[0])
+		if !ok || len(fields) != 2 {
+			return getErrMessage(InvalidPeriodErr, mod.config)
+		}
+		year, _ = strconv.Atoi(fields[1])
+	}
+	query := StatsQuery{}
+	if month != 0 {
+		query = monthQuery(month, year)
+	}
+	query.UserId = topUserId
+	wordStats, err := mod.GetTopSwears(query, mod.config.TopSwearsLimit)
+	if err != Success {
+		return getErrMessage(err, mod.config)
+	}
+	if len(wordStats) == 0 {
+		return mod.config.OnEmptyRankResponse
+	}
+	userName := ""
+	if topUserId != "" {
+		var errResponse string
+		userName, errResponse = mod.getUserName(topUserId)
+		if errResponse != "" {
+			return errResponse
+		}
+	}
+	return formatTopSwears(mod.config, month, year, userName, wordStats)
+}
+
+func (mod *ModSwears) getUserReport(reportUserId string) string {
+	report, err := mod.GetUserReport(reportUserId)
+	if err != Success {
+		return getErrMessage(err, mod.config)
+	}
+	userName, errResponse := mod.getUserName(reportUserId)
+	if errResponse != "" {
+		return errResponse
+	}
+	if report == nil {
+		params := map[string]string{"user": userName}
+		return utils.ParamFormat(mod.config.OnNoUserStatsResponse, params)
+	}
+	return formatUserReport(mod.config, userName, report)
+}
+
+func (mod *ModSwears) getDebt(userId string) string {
+	balance, err := mod.GetJarBalance(userId)
+	if err != Success {
+		return getErrMessage(err, mod.config)
+	}
+	return formatJarBalance(mod.config, mod.config.OnMyDebtResponse, balance, nil)
+}
+
+func (mod *ModSwears) getJarTotal() string {
+	balance, err := mod.GetJarBalance("")
+	if err != Success {
+		return getErrMessage(err, mod.config)
+	}
+	return formatJarBalance(mod.config, mod.config.OnJarTotalResponse, balance, nil)
+}
+
+func (mod *ModSwears) addJarPayment(adminId string, payerId string, amountText string) string {
+	admin, errResponse := mod.isAdmin(adminId)
+	if errResponse != "" {
+		return errResponse
+	}
+	if !admin {
+		return getErrMessage(NotAdminErr, mod.config)
+	}
+	amount, _ := strconv.Atoi(amountText)
+	err := mod.AddJarPayment(payerId, amount, adminId, utils.TimeClock.Now())
+	if err != Success {
+		return getErrMessage(err, mod.config)
+	}
+	balance, err := mod.GetJarBalance(payerId)
+	if err != Success {
+		return getErrMessage(err, mod.config)
+	}
+	userName, errResponse := mod.getUserName(payerId)
+	if errResponse != "" {
+		return errResponse
+	}
+	params := map[string]string{
+		"user":   userName,
+		"amount": formatJarAmount(mod.config, amount),
+	}
+	return formatJarBalance(mod.config, mod.config.OnPaidResponse, balance, params)
+}
+
+// Pardons swears of the user in the month, the current one if no month is
+// given.
+func (mod *ModSwears) pardonSwears(
+	adminId string,
+	pardonUserId string,
+	countText string,
+	monthName string,
+	yearText string) string {
+
+	now := utils.TimeClock.Now()
+	month, year := int(now.Month()), now.Year()
+	if monthName != "" {
+		var ok bool
+		month, ok = parseMonth(mod.config.MonthNames, monthName)
+		if !ok {
+			return getErrMessage(InvalidPeriodErr, mod.config)
+		}
+		year, _ = strconv.Atoi(yearText)
+	}
+	count, _ := strconv.Atoi(countText)
+	return mod.correctStats(adminId, mod.config.OnPardonResponse, []string{pardonUserId}, func() (int, int) {
+		return mod.PardonSwears(pardonUserId, count, month, year, adminId, now)
+	})
+}
+
+func (mod *ModSwears) resetStats(adminId string, resetUserId string) string {
+	return mod.correctStats(adminId, mod.config.OnResetStatsResponse, []string{resetUserId}, func() (int, int) {
+		return mod.ResetStats(resetUserId, adminId, utils.TimeClock.Now())
+	})
+}
+
+func (mod *ModSwears) mergeUser(adminId string, mergeUserId string, targetUserId string) string {
+	userIds := []string{mergeUserId, targetUserId}
+	return mod.correctStats(adminId, mod.config.OnMergeUserResponse, userIds, func() (int, int) {
+		return mod.MergeUser(mergeUserId, targetUserId, adminId, utils.TimeClock.Now())
+	})
+}
+
+// Makes the correction if the user is an admin and formats the response
+// with number of corrected swears and names of corrected users.
+func (mod *ModSwears) correctStats(
+	adminId string,
+	format string,
+	userIds []string,
+	correct func() (int, int)) string {
+
+	admin, errResponse := mod.isAdmin(adminId)
+	if errResponse != "" {
+		return errResponse
+	}
+	if !admin {
+		return getErrMessage(NotAdminErr, mod.config)
+	}
+	count, err := correct()
+	if err != Success {
+		return getErrMessage(err, mod.config)
+	}
+	names, errResponse := mod.getUserNames(userIds)
+	if errResponse != "" {
+		return errResponse
+	}
+	params := map[string]string{
+		"count":  strconv.Itoa(count),
+		"user":   names[userIds[0]],
+		"target": names[userIds[len(userIds)-1]],
+	}
+	return utils.ParamFormat(format, params)
+}
+
+func (mod *ModSwears) getCorrections(correctedUserId string) string {
+	corrections := mod.GetCorrections(correctedUserId)
+	userIds := []string{correctedUserId}
+	for _, correction := range corrections {
+		userIds = append(userIds, correction.UserId, correction.TargetUserId, correction.AdminId)
+	}
+	names, errResponse := mod.getUserNames(userIds)
+	if errResponse != "" {
+		return errResponse
+	}
+	params := map[string]string{"user": names[correctedUserId]}
+	if len(corrections) == 0 {
+		return utils.ParamFormat(mod.config.OnNoCorrectionsResponse, params)
+	}
+	return formatCorrections(mod.config, names[correctedUserId], names, corrections)
+}
+
+// Returns names of users by their ids or response to send if users cannot
+// be fetched. Empty ids are left out.
+func (mod *ModSwears) getUserNames(userIds []string) (map[string]string, string) {
+	userStats := []*UserStats{}
+	for _, userId := range userIds {
+		if userId != "" {
+			userStats = append(userStats, &UserStats{UserId: userId})
+		}
+	}
+	errResponse := mod.fillUserRealNames(userStats)
+	if errResponse != "" {
+		return nil, errResponse
+	}
+	names := make(map[string]string, len(userStats))
+	i := 0
+	for _, userId := range userIds {
+		if userId != "" {
+			names[userId] = userStats[i].UserId
+			i++
+		}
+	}
+	return names, ""
+}
+
+// Returns whether the user is an admin or owner of the workspace, or
+// response to send if users cannot be fetched.
+func (mod *ModSwears) isAdmin(userId string) (bool, string) {
+	errResponse := mod.fetchMissingUsers([]string{userId})
+	if errResponse != "" {
+		return false, errResponse
+	}
+	user, ok := mod.state.Users().GetUser(userId)
+	return ok && (user.IsAdmin || user.IsOwner), ""
+}
+
+// Returns name of the user or response to send if users cannot be fetched.
+func (mod *ModSwears) getUserName(userId string) (string, string) {
+	user := []*UserStats{&UserStats{UserId: userId}}
+	errResponse := mod.fillUserRealNames(user)
+	return user[0].UserId, errResponse
 }
 
 func (mod *ModSwears) prepareRank(userStats []*UserStats, rankErr int) string {
@@ -201,7 +851,23 @@ func (mod *ModSwears) prepareRank(userStats []*UserStats, rankErr int) string {
 	if len(userStats) == 0 {
 		return mod.config.OnEmptyRankResponse
 	}
-	return fillUserRealNames(userStats, mod.state.SlackClient(), mod.config)
+	return mod.fillUserRealNames(userStats)
+}
+
+func (mod *ModSwears) migrateStats(adminId string) string {
+	admin, errResponse := mod.isAdmin(adminId)
+	if errResponse != "" {
+		return errResponse
+	}
+	if !admin {
+		return getErrMessage(NotAdminErr, mod.config)
+	}
+	count, err := mod.MigrateStats()
+	if err != Success {
+		return getErrMessage(err, mod.config)
+	}
+	params := map[string]string{"count": strconv.Itoa(count)}
+	return utils.ParamFormat(mod.config.OnMigrateStatsResponse, params)
 }
 
 func (mod *ModSwears) addRule(rule string) string {
@@ -212,6 +878,51 @@ func (mod *ModSwears) addRule(rule string) string {
 	return formatAddRuleResponse(mod.config.OnAddRuleResponse, rule)
 }
 
+func (mod *ModSwears) addException(rule string) string {
+	err := mod.AddException(rule)
+	if err != Success {
+		return getErrMessage(err, mod.config)
+	}
+	return formatAddRuleResponse(mod.config.OnAddExceptionResponse, rule)
+}
+
+func (mod *ModSwears) removeRule(rule string) string {
+	err := mod.RemoveRule(rule)
+	if err == LemmaFormRemoveErr {
+		lemmaRule, _ := mod.getRules().findEntry(rule)
+		return formatAddRuleResponse(mod.config.OnLemmaFormRemoveErr, lemmaRule)
+	}
+	if err != Success {
+		return getErrMessage(err, mod.config)
+	}
+	return formatAddRuleResponse(mod.config.OnRemoveRuleResponse, rule)
+}
+
+func (mod *ModSwears) listRules(prefix string) string {
+	rules := mod.ListRules(prefix)
+	if len(rules) == 0 {
+		return mod.config.OnNoRulesResponse
+	}
+	return formatRulesResponse(mod.config.OnListRulesResponse, mod.config, rules, mod.LemmaForms)
+}
+
+func (mod *ModSwears) whichRule(word string) string {
+	rule, ok := mod.WhichRule(word)
+	if !ok {
+		return formatRuleMatchResponse(mod.config.OnNoRuleMatchResponse, word, "")
+	}
+	return formatRuleMatchResponse(mod.config.OnRuleMatchResponse, word, rule)
+}
+
+func (mod *ModSwears) reloadRules() string {
+	err := mod.ReloadRules()
+	if err != Success {
+		return getErrMessage(err, mod.config)
+	}
+	params := map[string]string{"count": strconv.Itoa(mod.RuleCount())}
+	return utils.ParamFormat(mod.config.OnReloadRulesResponse, params)
+}
+
 func (mod *ModSwears) setSwearNotify(
 	userId string,
 	channelId string,
@@ -228,34 +939,115 @@ func (mod *ModSwears) setSwearNotify(
 	return mod.config.OnSwearNotifyOffResponse
 }
 
-func fillUserRealNames(
-	userStats []*UserStats,
-	slack *slack.Client,
-	config *ModSwearsConfig) string {
-
-	users, usersErr := slack.GetUsers()
-	if usersErr != nil {
-		log.Printf("ModSwears: Cannot fetch users from slack: %s\n", usersErr)
-		return config.OnUserFetchErr
+// Replaces ids of users with their names from the user directory.
+func (mod *ModSwears) fillUserRealNames(userStats []*UserStats) string {
+	userIds := make([]string, len(userStats))
+	for i, userStat := range userStats {
+		userIds[i] = userStat.UserId
+	}
+	errResponse := mod.fetchMissingUsers(userIds)
+	if errResponse != "" {
+		return errResponse
 	}
 	for _, userStat := range userStats {
-		user, ok := getUserById(users, userStat.UserId)
+		user, ok := mod.state.Users().GetUser(userStat.UserId)
 		if !ok {
 			userStat.UserId = "unknown"
 		} else {
-			userStat.UserId = user.Name
+			userStat.UserId = getUserName(user, mod.config.UserNameSource)
 		}
 	}
 	return ""
 }
 
-func getUserById(users []slack.User, id string) (slack.User, bool) {
-	for _, user := range users {
-		if user.ID == id {
-			return user, true
+// Fetches users from slack if any of the users is not in the user
+// directory. If slack cannot be reached, names are taken from the
+// directory as long as it is not empty. Users slack did not return are
+// not fetched again until UserFetchRetrySec passes.
+func (mod *ModSwears) fetchMissingUsers(userIds []string) string {
+	now := utils.TimeClock.Now()
+	missing := mod.missingUsers(userIds, now)
+	if len(missing) == 0 {
+		return ""
+	}
+	directory := mod.state.Users()
+	users, usersErr := mod.state.SlackClient().GetUsers()
+	if usersErr != nil {
+		log.Printf("ModSwears: Cannot fetch users from slack: %s\n", usersErr)
+		if directory.Len() == 0 {
+			return mod.config.OnUserFetchErr
+		}
+		return ""
+	}
+	directory.SetUsers(users)
+	mod.rememberMissedUsers(missing, now)
+	return ""
+}
+
+// Returns users which are neither in the user directory nor missed by
+// a fetch within the last UserFetchRetrySec.
+func (mod *ModSwears) missingUsers(userIds []string, now time.Time) []string {
+	retry := time.Duration(mod.config.UserFetchRetrySec) * time.Second
+	directory := mod.state.Users()
+	mod.missedUsersMutex.Lock()
+	defer mod.missedUsersMutex.Unlock()
+	missing := []string{}
+	for _, userId := range userIds {
+		if _, ok := directory.GetUser(userId); ok {
+			continue
+		}
+		if missedAt, ok := mod.missedUsers[userId]; ok && now.Sub(missedAt) < retry {
+			continue
 		}
+		missing = append(missing, userId)
 	}
-	return slack.User{}, false
+	return missing
+}
+
+// Remembers users which are still not in the user directory after
+// a fetch.
+func (mod *ModSwears) rememberMissedUsers(userIds []string, now time.Time) {
+	directory := mod.state.Users()
+	mod.missedUsersMutex.Lock()
+	defer mod.missedUsersMutex.Unlock()
+	for _, userId := range userIds {
+		if _, ok := directory.GetUser(userId); ok {
+			delete(mod.missedUsers, userId)
+		} else {
+			mod.missedUsers[userId] = now
+		}
+	}
+}
+
+// Returns channel the rank is limited to: the channel mentioned in the
+// command, the channel the command was sent to if asked for rank here, or
+// no channel for the workspace rank.
+func getRankChannelId(groups []string, channelId string) string {
+	if groups[2] != "" {
+		return groups[2]
+	}
+	if groups[1] != "" {
+		return channelId
+	}
+	return ""
+}
+
+// Returns display name or real name of the user as set in config, falling
+// back to the real name and then to the user name if the chosen one is
+// empty.
+func getUserName(user slack.User, source string) string {
+	names := []string{user.Profile.DisplayName, user.RealName, user.Profile.RealName, user.Name}
+	if source == UserNameReal {
+		names = names[1:]
+	} else if source == UserNameLogin {
+		names = names[3:]
+	}
+	for _, name := range names {
+		if name != "" {
+			return name
+		}
+	}
+	return ""
 }
 
 func formatAddRuleResponse(format string, rule string) string {
@@ -263,6 +1055,37 @@ func formatAddRuleResponse(format string, rule string) string {
 	return utils.ParamFormat(format, params)
 }
 
+func formatRulesResponse(
+	responseFormat string,
+	config *ModSwearsConfig,
+	rules []string,
+	lemmaForms func(string) ([]string, bool)) string {
+
+	formatted := make([]string, len(rules))
+	for i, rule := range rules {
+		if forms, ok := lemmaForms(rule); ok {
+			formatted[i] = formatLemmaRule(config.LemmaRuleFormat, rule, forms)
+		} else {
+			formatted[i] = formatAddRuleResponse(config.RuleFormat, rule)
+		}
+	}
+	params := map[string]string{
+		"rules": strings.Join(formatted, ", "),
+		"count": strconv.Itoa(len(rules)),
+	}
+	return utils.ParamFormat(responseFormat, params)
+}
+
+func formatLemmaRule(format string, rule string, forms []string) string {
+	params := map[string]string{"rule": rule, "forms": strings.Join(forms, ", ")}
+	return utils.ParamFormat(format, params)
+}
+
+func formatRuleMatchResponse(format string, word string, rule string) string {
+	params := map[string]string{"word": word, "rule": rule}
+	return utils.ParamFormat(format, params)
+}
+
 func formatSwearsResponse(
 	lineFormat string,
 	swearFormat string,
@@ -287,44 +1110,241 @@ func formatMonthlyRank(
 	config *ModSwearsConfig,
 	month int,
 	year int,
+	channelId string,
 	userStats []*UserStats) string {
 
+	headerFormat := config.MonthlyRankHeaderFormat
+	if channelId != "" {
+		headerFormat = config.MonthlyChannelRankHeaderFormat
+	}
 	header := formatMonthlyRankHeader(
-		config.MonthlyRankHeaderFormat,
+		headerFormat,
 		config.MonthNames,
 		month,
-		year)
-	rankLines := formatRankLines(config.RankLineFormat, userStats)
+		year,
+		formatChannel(config.ChannelFormat, channelId))
+	rankLines := formatRankLines(config, userStats)
 	return fmt.Sprintf("%s\n%s", header, rankLines)
 }
 
 func formatTotalRank(
 	config *ModSwearsConfig,
+	channelId string,
 	userStats []*UserStats) string {
 
 	header := config.TotalRankHeaderFormat
-	rankLines := formatRankLines(config.RankLineFormat, userStats)
+	if channelId != "" {
+		params := map[string]string{"channel": formatChannel(config.ChannelFormat, channelId)}
+		header = utils.ParamFormat(config.TotalChannelRankHeaderFormat, params)
+	}
+	rankLines := formatRankLines(config, userStats)
 	return fmt.Sprintf("%s\n%s", header, rankLines)
 }
 
+func formatChannelRank(config *ModSwearsConfig, channelStats []*ChannelStats) string {
+	var buffer bytes.Buffer
+	for i, channelStat := range channelStats {
+		params := map[string]string{
+			"index":   strconv.Itoa(i + 1),
+			"channel": formatChannel(config.ChannelFormat, channelStat.ChannelId),
+			"count":   strconv.Itoa(channelStat.SwearCount),
+			"score":   strconv.Itoa(channelStat.Score),
+		}
+		buffer.WriteString(utils.ParamFormat(config.ChannelRankLineFormat, params))
+		buffer.WriteString("\n")
+	}
+	return fmt.Sprintf("%s\n%s", config.ChannelRankHeaderFormat, buffer.String())
+}
+
+func formatUserReport(config *ModSwearsConfig, userName string, report *UserReport) string {
+	params := map[string]string{
+		"user":          userName,
+		"monthcount":    strconv.Itoa(report.Month.SwearCount),
+		"monthscore":    strconv.Itoa(report.Month.Score),
+		"monthposition": formatRankPosition(config, report.MonthPosition, report.MonthUsers),
+		"totalcount":    strconv.Itoa(report.Total.SwearCount),
+		"totalscore":    strconv.Itoa(report.Total.Score),
+		"totalposition": formatRankPosition(config, report.TotalPosition, report.TotalUsers),
+		"bestmonth":     formatStatsMonth(config, report.BestMonth),
+		"bestcount":     strconv.Itoa(monthSwearCount(report.BestMonth)),
+		"worstmonth":    formatStatsMonth(config, report.WorstMonth),
+		"worstcount":    strconv.Itoa(monthSwearCount(report.WorstMonth)),
+		"trend":         formatTrend(config, report.Month, report.PrevMonth),
+		"streak":        strconv.Itoa(report.StreakDays),
+	}
+	return utils.ParamFormat(config.UserReportFormat, params)
+}
+
+// Formats corrections of the user, names of all users are taken from the
+// map of names by user id.
+func formatCorrections(
+	config *ModSwearsConfig,
+	userName string,
+	names map[string]string,
+	corrections []*StatsCorrection) string {
+
+	var buffer bytes.Buffer
+	params := map[string]string{"user": userName}
+	buffer.WriteString(utils.ParamFormat(config.CorrectionsHeaderFormat, params))
+	for _, correction := range corrections {
+		format := config.PardonCorrectionFormat
+		if correction.Kind == CorrectionReset {
+			format = config.ResetCorrectionFormat
+		} else if correction.Kind == CorrectionMerge {
+			format = config.MergeCorrectionFormat
+		}
+		params := map[string]string{
+			"time":   correction.Time.Format(config.DateFormat),
+			"admin":  names[correction.AdminId],
+			"user":   names[correction.UserId],
+			"target": names[correction.TargetUserId],
+			"count":  strconv.Itoa(correction.Count),
+		}
+		if correction.Kind == CorrectionPardon {
+			monthStats := &MonthStats{Month: correction.Month, Year: correction.Year}
+			params["month"] = formatStatsMonth(config, monthStats)
+		}
+		buffer.WriteString("\n")
+		buffer.WriteString(utils.ParamFormat(format, params))
+	}
+	return buffer.String()
+}
+
+// Formats the balance with extra params added.
+func formatJarBalance(
+	config *ModSwearsConfig,
+	format string,
+	balance JarBalance,
+	params map[string]string) string {
+
+	if params == nil {
+		params = map[string]string{}
+	}
+	params["fines"] = formatJarAmount(config, balance.Fines)
+	params["paid"] = formatJarAmount(config, balance.Paid)
+	params["debt"] = formatJarAmount(config, balance.Debt())
+	return utils.ParamFormat(format, params)
+}
+
+func formatJarAmount(config *ModSwearsConfig, amount int) string {
+	params := map[string]string{"amount": strconv.Itoa(amount)}
+	return utils.ParamFormat(config.JarAmountFormat, params)
+}
+
+func formatRankPosition(config *ModSwearsConfig, position int, users int) string {
+	if position == 0 {
+		return config.NotRankedFormat
+	}
+	params := map[string]string{
+		"position": strconv.Itoa(position),
+		"users":    strconv.Itoa(users),
+	}
+	return utils.ParamFormat(config.RankPositionFormat, params)
+}
+
+func formatStatsMonth(config *ModSwearsConfig, monthStats *MonthStats) string {
+	params := map[string]string{
+		"month":    config.MonthNames[monthStats.Month-1],
+		"monthnum": strconv.Itoa(monthStats.Month),
+		"year":     strconv.Itoa(monthStats.Year),
+	}
+	return utils.ParamFormat(config.StatsMonthFormat, params)
+}
+
+// Compares swear counts of this and last month.
+func formatTrend(config *ModSwearsConfig, month *UserStats, prevMonth *UserStats) string {
+	diff := month.SwearCount - prevMonth.SwearCount
+	format := config.TrendSameFormat
+	if diff > 0 {
+		format = config.TrendUpFormat
+	} else if diff < 0 {
+		format = config.TrendDownFormat
+		diff = -diff
+	}
+	params := map[string]string{"diff": strconv.Itoa(diff)}
+	return utils.ParamFormat(format, params)
+}
+
+func monthSwearCount(monthStats *MonthStats) int {
+	count := 0
+	for _, user := range monthStats.Users {
+		count += user.SwearCount
+	}
+	return count
+}
+
+// Formats header of rank of days in [From, To) of the query, showing the
+// last day of the period.
+func formatPeriodRankHeader(headerFormat string, dateFormat string, query StatsQuery) string {
+	params := map[string]string{
+		"from": query.From.Format(dateFormat),
+		"to":   query.To.AddDate(0, 0, -1).Format(dateFormat),
+	}
+	return utils.ParamFormat(headerFormat, params)
+}
+
+// Month is zero for all time top swears and user is empty for top swears
+// of everyone.
+func formatTopSwears(
+	config *ModSwearsConfig,
+	month int,
+	year int,
+	user string,
+	wordStats []*WordStats) string {
+
+	headerFormat := config.TopSwearsHeaderFormat
+	params := map[string]string{"user": user}
+	if month != 0 {
+		headerFormat = config.MonthlyTopSwearsHeaderFormat
+		params["month"] = config.MonthNames[month-1]
+		params["monthnum"] = strconv.Itoa(month)
+		params["year"] = strconv.Itoa(year)
+	}
+	if user != "" && month != 0 {
+		headerFormat = config.MonthlyUserTopSwearsHeaderFormat
+	} else if user != "" {
+		headerFormat = config.UserTopSwearsHeaderFormat
+	}
+	var buffer bytes.Buffer
+	for i, wordStat := range wordStats {
+		lineParams := map[string]string{
+			"index": strconv.Itoa(i + 1),
+			"swear": wordStat.Word,
+			"count": strconv.Itoa(wordStat.SwearCount),
+			"score": strconv.Itoa(wordStat.Score),
+		}
+		buffer.WriteString(utils.ParamFormat(config.TopSwearLineFormat, lineParams))
+		buffer.WriteString("\n")
+	}
+	header := utils.ParamFormat(headerFormat, params)
+	return fmt.Sprintf("%s\n%s", header, buffer.String())
+}
+
+func formatChannel(format string, channelId string) string {
+	params := map[string]string{"channel": channelId}
+	return utils.ParamFormat(format, params)
+}
+
 func formatMonthlyRankHeader(
 	headerFormat string,
 	monthNames []string,
 	month int,
-	year int) string {
+	year int,
+	channel string) string {
 
 	params := map[string]string{
 		"month":    monthNames[month-1],
 		"monthnum": strconv.Itoa(month),
 		"year":     strconv.Itoa(year),
+		"channel":  channel,
 	}
 	return utils.ParamFormat(headerFormat, params)
 }
 
-func formatRankLines(lineFormat string, userStats []*UserStats) string {
+func formatRankLines(config *ModSwearsConfig, userStats []*UserStats) string {
 	var buffer bytes.Buffer
 	for i, userStat := range userStats {
-		line := formatRankLine(lineFormat, userStat.UserId, userStat.SwearCount, i+1)
+		line := formatRankLine(config, userStat, i+1)
 		buffer.WriteString(line)
 		buffer.WriteString("\n")
 	}
@@ -332,13 +1352,19 @@ func formatRankLines(lineFormat string, userStats []*UserStats) string {
 	return buffer.String()
 }
 
-func formatRankLine(lineFormat string, user string, count int, index int) string {
+func formatRankLine(config *ModSwearsConfig, userStat *UserStats, index int) string {
+	category := topCategory(userStat.Categories)
+	if category == "" {
+		category = config.DefaultRuleCategory
+	}
 	params := map[string]string{
-		"index": strconv.Itoa(index),
-		"user":  user,
-		"count": strconv.Itoa(count),
+		"index":    strconv.Itoa(index),
+		"user":     userStat.UserId,
+		"count":    strconv.Itoa(userStat.SwearCount),
+		"score":    strconv.Itoa(userStat.Score),
+		"category": category,
 	}
-	return utils.ParamFormat(lineFormat, params)
+	return utils.ParamFormat(config.RankLineFormat, params)
 }
 
 //TODO: move settings responses to some general config
@@ -352,10 +1378,44 @@ func getErrMessage(err int, config *ModSwearsConfig) string {
 		return config.OnAddRuleSaveErr
 	case InvalidWildcardErr:
 		return config.OnInvalidWildcardErr
+	case RuleNotFoundErr:
+		return config.OnRuleNotFoundErr
+	case ExceptionConflictErr:
+		return config.OnExceptionConflictErr
+	case InvalidRegexRuleErr:
+		return config.OnInvalidRegexRuleErr
+	case RegexRuleTooComplexErr:
+		return config.OnRegexRuleTooComplexErr
+	case RegexRuleTooBroadErr:
+		return config.OnRegexRuleTooBroadErr
+	case UnknownLemmaErr:
+		return config.OnUnknownLemmaErr
+	case LemmaFormRemoveErr:
+		return config.OnLemmaFormRemoveErr
+	case RuleInfoFileReadErr:
+		return config.OnRuleInfoFileReadErr
 	case StatsFileReadErr:
 		return config.OnStatsFileReadErr
 	case StatsSaveErr:
 		return config.OnStatsSaveErr
+	case StatsMigrationErr:
+		return config.OnStatsMigrationErr
+	case InvalidPeriodErr:
+		return config.OnInvalidPeriodErr
+	case JarFileReadErr:
+		return config.OnJarFileReadErr
+	case JarSaveErr:
+		return config.OnJarSaveErr
+	case NotAdminErr:
+		return config.OnNotAdminErr
+	case InvalidJarPaymentErr:
+		return config.OnInvalidJarPaymentErr
+	case CorrectionsFileReadErr:
+		return config.OnCorrectionsFileReadErr
+	case CorrectionsSaveErr:
+		return config.OnCorrectionsSaveErr
+	case NoSwearsToCorrectErr:
+		return config.OnNoSwearsToCorrectErr
 	case settings.SettingsFileReadErr:
 		return config.OnSettingsFileReadErr
 	case settings.SettingsSaveErr:
@@ -0,0 +1,92 @@
+package modswears
+
+import (
+	"../../mods"
+	"../../utils"
+	"github.com/nlopes/slack"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFillUserRealNames(t *testing.T) {
+	mod := NewModSwears()
+	mod.config.UserNameSource = UserNameDisplay
+	mod.state = mods.NewState(nil, make(chan mods.Response))
+	mod.state.Users().SetUsers([]slack.User{
+		slack.User{ID: "U1", Name: "john", RealName: "John Smith"},
+		slack.User{ID: "U2", Name: "jane", Profile: slack.UserProfile{DisplayName: "Jane"}},
+	})
+	userStats := []*UserStats{&UserStats{UserId: "U2"}, &UserStats{UserId: "U1"}}
+	errResponse := mod.fillUserRealNames(userStats)
+	if errResponse != "" || userStats[0].UserId != "Jane" || userStats[1].UserId != "John Smith" {
+		t.Fatalf("Expected names of users from the directory, got %s, %s, '%s'",
+			userStats[0].UserId, userStats[1].UserId, errResponse)
+	}
+}
+
+func TestFillUserLoginNamesByDefault(t *testing.T) {
+	mod := NewModSwears()
+	mod.state = mods.NewState(nil, make(chan mods.Response))
+	mod.state.Users().SetUsers([]slack.User{
+		slack.User{ID: "U1", Name: "john", RealName: "John Smith"},
+	})
+	userStats := []*UserStats{&UserStats{UserId: "U1"}}
+	errResponse := mod.fillUserRealNames(userStats)
+	if errResponse != "" || userStats[0].UserId != "john" {
+		t.Fatalf("Expected login name of the user, got %s, '%s'", userStats[0].UserId, errResponse)
+	}
+}
+
+func TestMissedUsersNotFetchedAgain(t *testing.T) {
+	defer func() { utils.TimeClock = utils.RealClock{} }()
+	utils.TimeClock = utils.MockClock{CurrentTime: utils.NewLocalDateTime(2016, 3, 10, 12, 0, 0)}
+	mod := NewModSwears()
+	mod.state = mods.NewState(nil, make(chan mods.Response))
+	mod.state.Users().SetUsers([]slack.User{slack.User{ID: "U1", Name: "john"}})
+	now := utils.TimeClock.Now()
+	assertMissingUsers(t, mod, []string{"U1", "U2", "U3"}, now, "U2", "U3")
+	mod.state.Users().SetUser(slack.User{ID: "U3", Name: "jane"})
+	mod.rememberMissedUsers([]string{"U2", "U3"}, now)
+	assertMissingUsers(t, mod, []string{"U1", "U2", "U3", "U4"}, now.Add(time.Minute), "U4")
+	retry := time.Duration(mod.config.UserFetchRetrySec) * time.Second
+	assertMissingUsers(t, mod, []string{"U2", "U3"}, now.Add(retry), "U2")
+}
+
+func assertMissingUsers(
+	t *testing.T,
+	mod *ModSwears,
+	userIds []string,
+	now time.Time,
+	expected ...string) {
+
+	actual := mod.missingUsers(userIds, now)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected missing users %v, got %v", expected, actual)
+	}
+}
+
+func TestGetUserName(t *testing.T) {
+	user := slack.User{
+		Name:     "john",
+		RealName: "John Smith",
+		Profile:  slack.UserProfile{DisplayName: "Johnny"},
+	}
+	assertGetUserName(t, user, UserNameDisplay, "Johnny")
+	assertGetUserName(t, user, UserNameReal, "John Smith")
+	assertGetUserName(t, user, UserNameLogin, "john")
+	user.Profile.DisplayName = ""
+	assertGetUserName(t, user, UserNameDisplay, "John Smith")
+	user.RealName = ""
+	user.Profile.RealName = "John P. Smith"
+	assertGetUserName(t, user, UserNameReal, "John P. Smith")
+	user.Profile.RealName = ""
+	assertGetUserName(t, user, UserNameDisplay, "john")
+}
+
+func assertGetUserName(t *testing.T, user slack.User, source string, expected string) {
+	actual := getUserName(user, source)
+	if actual != expected {
+		t.Fatalf("Expected %s name '%s', got '%s'", source, expected, actual)
+	}
+}
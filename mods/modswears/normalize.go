@@ -0,0 +1,122 @@
+package modswears
+
+import (
+	"../../dictmatch"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type wordNormalizer struct {
+	separators map[rune]bool
+	masks      map[rune]bool
+	lookalikes map[rune]rune
+}
+
+func newWordNormalizer(config *ModSwearsConfig) *wordNormalizer {
+	normalizer := &wordNormalizer{
+		separators: map[rune]bool{},
+		masks:      map[rune]bool{},
+		lookalikes: map[rune]rune{},
+	}
+	if config.RemoveSeparators {
+		for _, r := range config.SeparatorChars {
+			normalizer.separators[r] = true
+		}
+	}
+	if config.ExpandMaskChars {
+		for _, r := range config.MaskChars {
+			normalizer.masks[r] = true
+		}
+	}
+	if config.ReplaceLookalikes {
+		for from, to := range config.LookalikeChars {
+			fromRune, _ := utf8.DecodeRuneInString(strings.ToLower(from))
+			toRune, _ := utf8.DecodeRuneInString(strings.ToLower(to))
+			normalizer.lookalikes[fromRune] = toRune
+		}
+	}
+	return normalizer
+}
+
+func dictMode(config *ModSwearsConfig) int {
+	mode := 0
+	if config.FoldDiacritics {
+		mode |= dictmatch.FoldDiacritics
+	}
+	if config.CollapseRepeatedChars {
+		mode |= dictmatch.CollapseRepeats
+	}
+	return mode
+}
+
+// Normalizes dictionary rule, wildcards are left untouched and words of
+// phrase rules are separated with single spaces.
+func (n *wordNormalizer) normalizeRule(rule string) string {
+	rule = strings.Join(strings.Fields(rule), " ")
+	return n.replaceLookalikes(normalizeWord(rule))
+}
+
+// Normalizes word before it is matched against dictionary: lookalike
+// characters are replaced, mask characters inside the word are expanded
+// to dictmatch.AnyRune and separators are removed.
+func (n *wordNormalizer) normalizeSwear(word string) string {
+	runes := []rune(n.replaceLookalikes(normalizeWord(word)))
+	first, last := letterBounds(runes)
+	result := make([]rune, 0, len(runes))
+	for i, r := range runes {
+		if n.masks[r] && i > first && i < last {
+			result = append(result, dictmatch.AnyRune)
+		} else if r != dictmatch.AnyRune && !n.separators[r] {
+			result = append(result, r)
+		}
+	}
+	return string(result)
+}
+
+// Normalizes whole message for substring scanning, rune by rune. Returns
+// the normalized text and the offset in message of every byte in the text.
+func (n *wordNormalizer) normalizeText(message string) (string, []int) {
+	buffer := make([]byte, 0, len(message))
+	offsets := make([]int, 0, len(message)+1)
+	encoded := make([]byte, utf8.UTFMax)
+	for offset, r := range message {
+		r = unicode.ToLower(r)
+		if replacement, ok := n.lookalikes[r]; ok {
+			r = replacement
+		}
+		width := utf8.EncodeRune(encoded, r)
+		buffer = append(buffer, encoded[:width]...)
+		for i := 0; i < width; i++ {
+			offsets = append(offsets, offset)
+		}
+	}
+	offsets = append(offsets, len(message))
+	return string(buffer), offsets
+}
+
+func (n *wordNormalizer) replaceLookalikes(word string) string {
+	if len(n.lookalikes) == 0 {
+		return word
+	}
+	return strings.Map(func(r rune) rune {
+		if replacement, ok := n.lookalikes[r]; ok {
+			return replacement
+		}
+		return r
+	}, word)
+}
+
+// Returns indexes of the first and the last letter or digit in runes.
+func letterBounds(runes []rune) (int, int) {
+	first, last := len(runes), -1
+	for i, r := range runes {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if i < first {
+				first = i
+			}
+			last = i
+		}
+	}
+	return first, last
+}
@@ -0,0 +1,88 @@
+package modswears
+
+import (
+	"os"
+	"testing"
+)
+
+func TestNormalizeSwear(t *testing.T) {
+	normalizer := newWordNormalizer(NewModSwearsConfig())
+	assertNormalizeSwear(t, normalizer, " KuRwA\n", "kurwa")
+	assertNormalizeSwear(t, normalizer, "k.u.r.w.a", "kurwa")
+	assertNormalizeSwear(t, normalizer, "k-u_r+w~a", "kurwa")
+	assertNormalizeSwear(t, normalizer, "ch0j", "choj")
+	assertNormalizeSwear(t, normalizer, "$p13rd", "spierd")
+	assertNormalizeSwear(t, normalizer, "kurwа", "kurwa")
+	assertNormalizeSwear(t, normalizer, "d*pa", "d?pa")
+	assertNormalizeSwear(t, normalizer, "*dupa*", "dupa")
+	assertNormalizeSwear(t, normalizer, "dupa?", "dupa")
+	assertNormalizeSwear(t, normalizer, "***", "")
+}
+
+func TestNormalizeSwearDisabled(t *testing.T) {
+	config := NewModSwearsConfig()
+	config.RemoveSeparators = false
+	config.ExpandMaskChars = false
+	config.ReplaceLookalikes = false
+	normalizer := newWordNormalizer(config)
+	assertNormalizeSwear(t, normalizer, "k.u.r.w.a", "k.u.r.w.a")
+	assertNormalizeSwear(t, normalizer, "ch0j", "ch0j")
+	assertNormalizeSwear(t, normalizer, "d*pa", "d*pa")
+}
+
+func TestNormalizeRule(t *testing.T) {
+	normalizer := newWordNormalizer(NewModSwearsConfig())
+	assertNormalizeRule(t, normalizer, " Ch0J* ", "choj*")
+	assertNormalizeRule(t, normalizer, "*pierd*", "*pierd*")
+}
+
+func TestFindObfuscatedSwears(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := createSwears(t, tmpFileName)
+	expected := []string{"a.b.c.d", "aabbccdd", "4bcd", "ab*d", "abbbbba"}
+	assertFindSwears(t, mod, "a.b.c.d aabbccdd 4bcd ab*d abbbbba abc", expected)
+}
+
+func TestFindObfuscatedSwearsDisabled(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := NewModSwears()
+	mod.config.CollapseRepeatedChars = false
+	mod.config.RemoveSeparators = false
+	mod.config.ExpandMaskChars = false
+	mod.config.ReplaceLookalikes = false
+	mod.dictFileName = tmpFileName
+	if err := mod.LoadSwears(); err != Success {
+		t.Fatalf("Expected to load dictionary without errors, got %v", err)
+	}
+	expected := []string{"abbbbba"}
+	assertFindSwears(t, mod, "a.b.c.d aabbccdd 4bcd ab*d abbbbba", expected)
+}
+
+func TestFindSwearsWithDiacritics(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := createSwears(t, tmpFileName)
+	assertAddRule(t, mod, "gęś")
+	assertAddRuleErr(t, mod, "ges", AddRuleConflictErr)
+	expected := []string{"gęś", "ges", "gęs", "ąbcd"}
+	assertFindSwears(t, mod, "Gęś ges gęs gęsi ąbcd", expected)
+}
+
+func assertNormalizeSwear(t *testing.T, n *wordNormalizer, word string, expected string) {
+	actual := n.normalizeSwear(word)
+	if actual != expected {
+		t.Fatalf("Expected '%s' to be normalized to '%s', got '%s'", word, expected, actual)
+	}
+}
+
+func assertNormalizeRule(t *testing.T, n *wordNormalizer, rule string, expected string) {
+	actual := n.normalizeRule(rule)
+	if actual != expected {
+		t.Fatalf("Expected rule '%s' to be normalized to '%s', got '%s'", rule, expected, actual)
+	}
+}
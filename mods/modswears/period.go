@@ -0,0 +1,49 @@
+package modswears
+
+import (
+	"../../utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	InvalidPeriodErr = 15
+)
+
+const dateLayout = "2006-01-02"
+
+// Returns number of the month named as in config, ignoring case, or given
+// as a number.
+func parseMonth(monthNames []string, name string) (int, bool) {
+	for i, monthName := range monthNames {
+		if strings.EqualFold(monthName, name) {
+			return i + 1, true
+		}
+	}
+	month, err := strconv.Atoi(name)
+	if err != nil || month < 1 || month > 12 {
+		return 0, false
+	}
+	return month, true
+}
+
+// Returns query of days from the first to the last date, both included.
+func parseDateRange(first string, last string) (StatsQuery, bool) {
+	from, fromErr := time.ParseInLocation(dateLayout, first, time.Local)
+	to, toErr := time.ParseInLocation(dateLayout, last, time.Local)
+	if fromErr != nil || toErr != nil || to.Before(from) {
+		return StatsQuery{}, false
+	}
+	return StatsQuery{From: from, To: to.AddDate(0, 0, 1)}, true
+}
+
+func weekQuery(date time.Time) StatsQuery {
+	from := utils.FirstDayOfWeek(date)
+	return StatsQuery{From: from, To: from.AddDate(0, 0, 7)}
+}
+
+func yearQuery(year int) StatsQuery {
+	from := utils.NewLocalDate(year, time.January, 1)
+	return StatsQuery{From: from, To: from.AddDate(1, 0, 0)}
+}
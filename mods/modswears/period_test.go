@@ -0,0 +1,70 @@
+package modswears
+
+import (
+	"../../utils"
+	"testing"
+)
+
+func TestParseMonth(t *testing.T) {
+	monthNames := NewModSwearsConfig().MonthNames
+	assertParseMonth(t, monthNames, "March", 3, true)
+	assertParseMonth(t, monthNames, "march", 3, true)
+	assertParseMonth(t, monthNames, "12", 12, true)
+	assertParseMonth(t, monthNames, "13", 0, false)
+	assertParseMonth(t, monthNames, "Marzec", 0, false)
+
+	monthNames[2] = "Marzec"
+	assertParseMonth(t, monthNames, "MARZEC", 3, true)
+}
+
+func TestParseDateRange(t *testing.T) {
+	query, ok := parseDateRange("2016-03-01", "2016-05-31")
+	if !ok || query.From != utils.NewLocalDate(2016, 3, 1) || query.To != utils.NewLocalDate(2016, 6, 1) {
+		t.Fatalf("Expected query of March to May 2016, got %v", query)
+	}
+	if _, ok := parseDateRange("2016-03-02", "2016-03-01"); ok {
+		t.Fatal("Expected reversed date range to be rejected")
+	}
+	if _, ok := parseDateRange("2016-02-30", "2016-03-01"); ok {
+		t.Fatal("Expected invalid date to be rejected")
+	}
+}
+
+func TestPeriodRank(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+
+	mod := createStats(t, tmpFilePath)
+	assertAddSwearCount(t, mod, 12, 2015, "user1", 1)
+	assertAddSwearCount(t, mod, 3, 2016, "user1", 2)
+	assertAddSwearCount(t, mod, 6, 2016, "user2", 4)
+
+	query, _ := parseDateRange("2016-03-01", "2016-05-31")
+	assertStatsQuery(t, mod, query, []*UserStats{
+		&UserStats{UserId: "user1", SwearCount: 2, Score: 2},
+	})
+	assertStatsQuery(t, mod, yearQuery(2016), []*UserStats{
+		&UserStats{UserId: "user2", SwearCount: 4, Score: 4},
+		&UserStats{UserId: "user1", SwearCount: 2, Score: 2},
+	})
+	assertStatsQuery(t, mod, weekQuery(utils.NewLocalDate(2016, 6, 3)), []*UserStats{
+		&UserStats{UserId: "user2", SwearCount: 4, Score: 4},
+	})
+}
+
+func TestFormatPeriodRankHeader(t *testing.T) {
+	config := NewModSwearsConfig()
+	query := weekQuery(utils.NewLocalDate(2016, 6, 15))
+	actual := formatPeriodRankHeader(config.WeeklyRankHeaderFormat, config.DateFormat, query)
+	expected := "*Weekly Rank* - 2016-06-13 - 2016-06-19"
+	if actual != expected {
+		t.Fatalf("Expected header '%s', got '%s'", expected, actual)
+	}
+}
+
+func assertParseMonth(t *testing.T, monthNames []string, name string, expected int, expectedOk bool) {
+	actual, ok := parseMonth(monthNames, name)
+	if actual != expected || ok != expectedOk {
+		t.Fatalf("Expected month %d (%v) for '%s', got %d (%v)", expected, expectedOk, name, actual, ok)
+	}
+}
@@ -0,0 +1,143 @@
+package modswears
+
+import (
+	"log"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	InvalidRegexRuleErr    = 27
+	RegexRuleTooComplexErr = 28
+	RegexRuleTooBroadErr   = 29
+)
+
+const regexRuleMarker = "/"
+
+// Character classes up to this size are specific enough to count as a
+// required letter of a regex rule.
+const regexRuleMaxClassSize = 4
+
+// Regex rule is written in the dictionary as /pattern/ and matched against
+// whole normalized words.
+type regexRule struct {
+	source string
+	regex  *regexp.Regexp
+}
+
+func isRegexRule(rule string) bool {
+	return len(rule) > 2*len(regexRuleMarker) &&
+		strings.HasPrefix(rule, regexRuleMarker) &&
+		strings.HasSuffix(rule, regexRuleMarker)
+}
+
+func (rules *swearRules) addRegexRule(source string) int {
+	if rules.findRegexRule(source) >= 0 {
+		log.Printf("ModSwears: add rule: regex rule '%s' already exists\n", source)
+		return AddRuleConflictErr
+	}
+	regex, err := compileRegexRule(source, rules.config)
+	if err != Success {
+		return err
+	}
+	rules.regexRules = append(rules.regexRules, &regexRule{source: source, regex: regex})
+	return Success
+}
+
+func (rules *swearRules) removeRegexRule(source string) bool {
+	i := rules.findRegexRule(source)
+	if i < 0 {
+		return false
+	}
+	rules.regexRules = append(rules.regexRules[:i], rules.regexRules[i+1:]...)
+	return true
+}
+
+func (rules *swearRules) findRegexRule(source string) int {
+	for i, rule := range rules.regexRules {
+		if rule.source == source {
+			return i
+		}
+	}
+	return -1
+}
+
+func (rules *swearRules) matchRegexRules(word string) (string, bool) {
+	for _, rule := range rules.regexRules {
+		if rule.regex.MatchString(word) {
+			return rule.source, true
+		}
+	}
+	return "", false
+}
+
+// Compiles regex rule anchored to the whole word. Rules compiling to too
+// large programs or requiring too few letters are rejected.
+func compileRegexRule(source string, config *ModSwearsConfig) (*regexp.Regexp, int) {
+	pattern := source[len(regexRuleMarker) : len(source)-len(regexRuleMarker)]
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		log.Printf("ModSwears: invalid regex rule '%s': %v\n", source, err)
+		return nil, InvalidRegexRuleErr
+	}
+	prog, err := syntax.Compile(parsed.Simplify())
+	if err != nil {
+		log.Printf("ModSwears: invalid regex rule '%s': %v\n", source, err)
+		return nil, InvalidRegexRuleErr
+	}
+	if len(prog.Inst) > config.RegexRuleMaxSize {
+		log.Printf("ModSwears: regex rule '%s' is too complex (%d instructions)\n", source, len(prog.Inst))
+		return nil, RegexRuleTooComplexErr
+	}
+	if required := requiredRunes(parsed); required < config.RegexRuleMinLetters {
+		log.Printf("ModSwears: regex rule '%s' is too broad (%d required letters)\n", source, required)
+		return nil, RegexRuleTooBroadErr
+	}
+	regex, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		log.Printf("ModSwears: invalid regex rule '%s': %v\n", source, err)
+		return nil, InvalidRegexRuleErr
+	}
+	return regex, Success
+}
+
+// Returns the minimal number of specific runes any match of the regex must
+// contain. Wildcard characters and wide character classes are not counted.
+func requiredRunes(re *syntax.Regexp) int {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return utf8.RuneCountInString(string(re.Rune))
+	case syntax.OpCharClass:
+		size := 0
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			size += int(re.Rune[i+1]-re.Rune[i]) + 1
+		}
+		if size <= regexRuleMaxClassSize {
+			return 1
+		}
+		return 0
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredRunes(re.Sub[0])
+	case syntax.OpRepeat:
+		return re.Min * requiredRunes(re.Sub[0])
+	case syntax.OpConcat:
+		sum := 0
+		for _, sub := range re.Sub {
+			sum += requiredRunes(sub)
+		}
+		return sum
+	case syntax.OpAlternate:
+		min := -1
+		for _, sub := range re.Sub {
+			required := requiredRunes(sub)
+			if min < 0 || required < min {
+				min = required
+			}
+		}
+		return min
+	default:
+		return 0
+	}
+}
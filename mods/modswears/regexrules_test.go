@@ -0,0 +1,68 @@
+package modswears
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFindRegexSwears(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := createSwears(t, tmpFileName)
+	assertAddRule(t, mod, " /p[ie]+rd[oa]l.*/ ")
+	assertAddRule(t, mod, "/xy?zz/")
+	assertDictFile(t, tmpFileName, "a\nabcd\nabb*\n/p[ie]+rd[oa]l.*/\n/xy?zz/\n")
+	expected := []string{"pierdolić", "pirdal", "xzz", "xyzz", "p.i.e.r.d.o.l"}
+	message := "pierdolić pirdal pardal xzz xyzz xyyzz zzxyzz p.i.e.r.d.o.l"
+	assertFindSwears(t, mod, message, expected)
+
+	mod = createSwears(t, tmpFileName)
+	assertFindSwears(t, mod, message, expected)
+}
+
+func TestRegexRuleException(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := createSwears(t, tmpFileName)
+	assertAddRule(t, mod, "/xyz.*/")
+	assertAddException(t, mod, "xyzw")
+	assertFindSwears(t, mod, "xyzw xyzq", []string{"xyzq"})
+	assertWhichRule(t, mod, "xyzq", "/xyz.*/", true)
+	assertWhichRule(t, mod, "xyzw", "", false)
+}
+
+func TestRemoveRegexRule(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := createSwears(t, tmpFileName)
+	assertAddRule(t, mod, "/xyz.*/")
+	assertListRules(t, mod, "x", []string{"/xyz.*/"})
+	assertRemoveRuleErr(t, mod, "/xyz/", RuleNotFoundErr)
+	assertRemoveRule(t, mod, "/xyz.*/")
+	assertDictFile(t, tmpFileName, "a\nabcd\nabb*\n")
+	assertFindSwears(t, mod, "xyzq", []string{})
+	assertListRules(t, mod, "x", []string{})
+}
+
+func TestAddRegexRuleErr(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := createSwears(t, tmpFileName)
+	assertAddRule(t, mod, "/xyz.*/")
+	assertAddRuleErr(t, mod, "/xyz.*/", AddRuleConflictErr)
+	assertAddRuleErr(t, mod, "/xy(z/", InvalidRegexRuleErr)
+	assertAddRuleErr(t, mod, "/x{2,1}/", InvalidRegexRuleErr)
+	assertAddRuleErr(t, mod, "/(xyz{30}){30}/", RegexRuleTooComplexErr)
+	assertAddRuleErr(t, mod, "/.*/", RegexRuleTooBroadErr)
+	assertAddRuleErr(t, mod, "/xy.*/", RegexRuleTooBroadErr)
+	assertAddRuleErr(t, mod, "/[a-z]+xy/", RegexRuleTooBroadErr)
+	assertAddRuleErr(t, mod, "/xyz|a/", RegexRuleTooBroadErr)
+	assertAddRuleErr(t, mod, "/(xyz)?/", RegexRuleTooBroadErr)
+	assertAddRule(t, mod, "/[ab]{3}/")
+	assertAddRule(t, mod, "/(xyq|qqq)+/")
+	assertDictFile(t, tmpFileName, "a\nabcd\nabb*\n/xyz.*/\n/[ab]{3}/\n/(xyq|qqq)+/\n")
+}
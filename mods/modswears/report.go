@@ -0,0 +1,154 @@
+package modswears
+
+import (
+	"../../utils"
+	"time"
+)
+
+// Stats of a single user. Positions are counted from one and are zero if
+// the user has no swears in the rank. Best and worst months are taken from
+// months since the first swear of the user, months without swears
+// included, and are compared as ranks are sorted.
+type UserReport struct {
+	UserId        string
+	Month         *UserStats
+	MonthPosition int
+	MonthUsers    int
+	PrevMonth     *UserStats
+	Total         *UserStats
+	TotalPosition int
+	TotalUsers    int
+	BestMonth     *MonthStats
+	WorstMonth    *MonthStats
+	StreakDays    int
+}
+
+// GetUserReport returns stats of the user, or nil if the user has no
+// swears at all.
+func (mod *ModSwears) GetUserReport(userId string) (*UserReport, int) {
+	now := utils.TimeClock.Now()
+	monthRank, err := mod.GetMonthlyRank(int(now.Month()), now.Year())
+	if err != Success {
+		return nil, err
+	}
+	totalRank, err := mod.GetTotalRank()
+	if err != Success {
+		return nil, err
+	}
+	events, err := mod.stats.Query(StatsQuery{UserId: userId})
+	if err != Success {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, Success
+	}
+	prevMonth := utils.LastDayOfPrevMonth(now)
+	prevRank := userStatsOf(monthEvents(events, int(prevMonth.Month()), prevMonth.Year()))
+	report := &UserReport{
+		UserId:     userId,
+		MonthUsers: len(monthRank),
+		TotalUsers: len(totalRank),
+		StreakDays: daysBetween(lastSwearTime(events), now),
+	}
+	report.PrevMonth, _ = findRankPosition(prevRank, userId)
+	report.Month, report.MonthPosition = findRankPosition(monthRank, userId)
+	report.Total, report.TotalPosition = findRankPosition(totalRank, userId)
+	report.BestMonth, report.WorstMonth = mod.bestAndWorstMonths(events, now)
+	return report, Success
+}
+
+// Returns value by which ranks are sorted.
+func (mod *ModSwears) rankValue(userStats *UserStats) int {
+	if mod.config.RankSortBy == RankSortByCount {
+		return userStats.SwearCount
+	}
+	return userStats.Score
+}
+
+func (mod *ModSwears) bestAndWorstMonths(events []SwearEvent, now time.Time) (*MonthStats, *MonthStats) {
+	var best, worst *MonthStats
+	bestValue, worstValue := 0, 0
+	first := events[0].Time
+	for _, event := range events {
+		if event.Time.Before(first) {
+			first = event.Time
+		}
+	}
+	first = first.In(time.Local)
+	month := utils.NewLocalDate(first.Year(), first.Month(), 1)
+	for !month.After(now) {
+		monthStats := &MonthStats{
+			Year:  month.Year(),
+			Month: int(month.Month()),
+			Users: userStatsOf(monthEvents(events, int(month.Month()), month.Year())),
+		}
+		value := 0
+		if len(monthStats.Users) > 0 {
+			value = mod.rankValue(monthStats.Users[0])
+		}
+		if best == nil || value < bestValue {
+			best, bestValue = monthStats, value
+		}
+		if worst == nil || value > worstValue {
+			worst, worstValue = monthStats, value
+		}
+		month = month.AddDate(0, 1, 0)
+	}
+	return best, worst
+}
+
+func monthEvents(events []SwearEvent, month int, year int) []SwearEvent {
+	query := monthQuery(month, year)
+	selected := []SwearEvent{}
+	for _, event := range events {
+		if query.matches(event) {
+			selected = append(selected, event)
+		}
+	}
+	return selected
+}
+
+func findRankPosition(rank []*UserStats, userId string) (*UserStats, int) {
+	for i, userStats := range rank {
+		if userStats.UserId == userId {
+			return userStats, i + 1
+		}
+	}
+	return &UserStats{UserId: userId}, 0
+}
+
+// Returns time of the latest swear which has not been reversed, or time
+// of the first event if all swears have been reversed. Swears moved from
+// another user count as swears of the user.
+func lastSwearTime(events []SwearEvent) time.Time {
+	swears := remainingSwearsOf(events)
+	if len(swears) == 0 {
+		first := events[0].Time
+		for _, event := range events {
+			if event.Time.Before(first) {
+				first = event.Time
+			}
+		}
+		return first
+	}
+	last := swears[0].Time
+	for _, swear := range swears {
+		if swear.Time.After(last) {
+			last = swear.Time
+		}
+	}
+	return last
+}
+
+// Returns number of calendar days from the first to the second date.
+func daysBetween(first time.Time, second time.Time) int {
+	first, second = first.In(time.Local), second.In(time.Local)
+	from := utils.NewLocalDate(first.Year(), first.Month(), first.Day())
+	to := utils.NewLocalDate(second.Year(), second.Month(), second.Day())
+	days := 0
+	for from.Before(to) {
+		from = from.AddDate(0, 0, 1)
+		days++
+	}
+	return days
+}
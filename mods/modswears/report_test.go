@@ -0,0 +1,116 @@
+package modswears
+
+import (
+	"../../utils"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestUserReport(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+	defer func() { utils.TimeClock = utils.RealClock{} }()
+	utils.TimeClock = utils.MockClock{CurrentTime: utils.NewLocalDateTime(2016, 3, 10, 12, 0, 0)}
+
+	mod := createStats(t, tmpFilePath)
+	assertAddSwearCount(t, mod, 1, 2016, "user1", 5)
+	assertAddSwearCount(t, mod, 2, 2016, "user1", 2)
+	assertAddSwearCount(t, mod, 3, 2016, "user1", 3)
+	assertAddSwearCount(t, mod, 1, 2016, "user2", 1)
+	assertAddSwearCount(t, mod, 3, 2016, "user2", 4)
+
+	report := assertUserReport(t, mod, "user1")
+	if report.Month.SwearCount != 3 || report.MonthPosition != 2 || report.MonthUsers != 2 {
+		t.Fatalf("Expected 3 swears at 2 of 2 this month, got %#v", report)
+	}
+	if report.PrevMonth.SwearCount != 2 {
+		t.Fatalf("Expected 2 swears last month, got %d", report.PrevMonth.SwearCount)
+	}
+	if report.Total.SwearCount != 10 || report.TotalPosition != 1 || report.TotalUsers != 2 {
+		t.Fatalf("Expected 10 swears at 1 of 2 in total, got %#v", report)
+	}
+	if report.BestMonth.Month != 2 || report.WorstMonth.Month != 1 {
+		t.Fatalf("Expected best month 2 and worst month 1, got %d and %d",
+			report.BestMonth.Month, report.WorstMonth.Month)
+	}
+	if report.StreakDays != 9 {
+		t.Fatalf("Expected streak of 9 days, got %d", report.StreakDays)
+	}
+
+	report = assertUserReport(t, mod, "user2")
+	if report.PrevMonth.SwearCount != 0 || report.BestMonth.Month != 2 {
+		t.Fatalf("Expected swear-free February of user2, got %#v", report)
+	}
+
+	if report = assertUserReport(t, mod, "user3"); report != nil {
+		t.Fatalf("Expected no report of user without swears, got %#v", report)
+	}
+}
+
+func TestUserReportStreakOfReversedSwears(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+	defer func() { utils.TimeClock = utils.RealClock{} }()
+	utils.TimeClock = utils.MockClock{CurrentTime: utils.NewLocalDateTime(2016, 3, 10, 12, 0, 0)}
+
+	mod := createStats(t, tmpFilePath)
+	assertAddSwears(t, mod, 3, 2016, "user1", "a")
+	addDeletedMessage(t, mod, "user1", utils.NewLocalDateTime(2016, 3, 8, 12, 0, 0))
+	addDeletedMessage(t, mod, "user2", utils.NewLocalDateTime(2016, 3, 5, 12, 0, 0))
+
+	if report := assertUserReport(t, mod, "user1"); report.StreakDays != 8 {
+		t.Fatalf("Expected streak of 8 days from the swear left, got %d", report.StreakDays)
+	}
+	if report := assertUserReport(t, mod, "user2"); report.StreakDays != 5 {
+		t.Fatalf("Expected streak of 5 days from the reversed swear, got %d", report.StreakDays)
+	}
+}
+
+func addDeletedMessage(t *testing.T, mod *ModSwears, userId string, messageTime time.Time) {
+	messageTs := strconv.FormatInt(messageTime.Unix(), 10) + ".000100"
+	assertChangeMessageSwears(t, mod, userId, messageTs, "b")
+	assertChangeMessageSwears(t, mod, userId, messageTs)
+}
+
+func TestFormatUserReport(t *testing.T) {
+	config := NewModSwearsConfig()
+	config.UserReportFormat = "{user}: {monthcount}/{monthscore} {monthposition}, " +
+		"{totalcount}/{totalscore} {totalposition}, " +
+		"{bestmonth} {bestcount}, {worstmonth} {worstcount}, {trend}, {streak}"
+	report := &UserReport{
+		UserId:        "user1",
+		Month:         &UserStats{SwearCount: 3, Score: 4},
+		MonthPosition: 2,
+		MonthUsers:    2,
+		PrevMonth:     &UserStats{SwearCount: 5},
+		Total:         &UserStats{SwearCount: 10, Score: 12},
+		TotalPosition: 1,
+		TotalUsers:    2,
+		BestMonth:     &MonthStats{Month: 2, Year: 2016, Users: []*UserStats{}},
+		WorstMonth:    &MonthStats{Month: 1, Year: 2016, Users: []*UserStats{&UserStats{SwearCount: 5}}},
+		StreakDays:    9,
+	}
+	assertFormatUserReport(t, config, report,
+		"john: 3/4 #2 of 2, 10/12 #1 of 2, February 2016 0, January 2016 5, 2 fewer than last month, 9")
+
+	report.Month, report.MonthPosition = &UserStats{}, 0
+	report.PrevMonth = &UserStats{}
+	assertFormatUserReport(t, config, report,
+		"john: 0/0 not ranked, 10/12 #1 of 2, February 2016 0, January 2016 5, same as last month, 9")
+}
+
+func assertUserReport(t *testing.T, mod *ModSwears, userId string) *UserReport {
+	report, err := mod.GetUserReport(userId)
+	if err != Success {
+		t.Fatalf("Expected no error when getting report of %s but got %v", userId, err)
+	}
+	return report
+}
+
+func assertFormatUserReport(t *testing.T, config *ModSwearsConfig, report *UserReport, expected string) {
+	actual := formatUserReport(config, "john", report)
+	if actual != expected {
+		t.Fatalf("Expected report '%s', got '%s'", expected, actual)
+	}
+}
@@ -0,0 +1,74 @@
+package modswears
+
+import (
+	"../../utils"
+	"log"
+)
+
+const (
+	RuleInfoFileReadErr = 41
+)
+
+// Rule categories
+const (
+	CategoryMild   = "mild"
+	CategoryVulgar = "vulgar"
+	CategorySlur   = "slur"
+)
+
+// Metadata of a dictionary rule, kept in a file next to the dictionary and
+// keyed by the rule as it is written in the dictionary. Zero weight means
+// the weight of the rule category is used.
+type RuleInfo struct {
+	Category    string
+	Weight      int
+	Description string `json:",omitempty"`
+}
+
+type RuleInfos struct {
+	Rules map[string]*RuleInfo
+}
+
+func (mod *ModSwears) LoadRuleInfos() int {
+	infos := newRuleInfos()
+	err := utils.JsonFromFileCreate(mod.ruleInfoFileName, infos)
+	if err != nil {
+		log.Printf("ModSwears: Cannot read rule infos from file '%s'\n", mod.ruleInfoFileName)
+		return RuleInfoFileReadErr
+	}
+	mod.rulesMutex.Lock()
+	defer mod.rulesMutex.Unlock()
+	rules := *mod.getRules()
+	rules.ruleInfos = infos
+	mod.setRules(&rules)
+	return Success
+}
+
+// GetRuleInfo returns metadata of the rule, filled with defaults from
+// config if the rule has no metadata.
+func (mod *ModSwears) GetRuleInfo(rule string) RuleInfo {
+	info := RuleInfo{Category: mod.config.DefaultRuleCategory}
+	if stored, ok := mod.getRules().ruleInfos.Rules[rule]; ok {
+		info = *stored
+		if info.Category == "" {
+			info.Category = mod.config.DefaultRuleCategory
+		}
+	}
+	if info.Weight <= 0 {
+		info.Weight = mod.config.CategoryWeights[info.Category]
+	}
+	if info.Weight <= 0 {
+		info.Weight = 1
+	}
+	return info
+}
+
+func isKnownCategory(category string) bool {
+	return category == CategoryMild || category == CategoryVulgar || category == CategorySlur
+}
+
+func newRuleInfos() *RuleInfos {
+	return &RuleInfos{
+		Rules: map[string]*RuleInfo{},
+	}
+}
@@ -0,0 +1,206 @@
+package modswears
+
+import (
+	"../../utils"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRuleInfoDefaults(t *testing.T) {
+	mod := NewModSwears()
+	mod.getRules().ruleInfos.Rules["a"] = &RuleInfo{Category: "slur"}
+	mod.getRules().ruleInfos.Rules["abcd"] = &RuleInfo{Category: "mild", Weight: 3}
+	mod.getRules().ruleInfos.Rules["abb*"] = &RuleInfo{Description: "abbey"}
+	mod.getRules().ruleInfos.Rules["xyz"] = &RuleInfo{Category: "unknown"}
+
+	assertRuleInfo(t, mod, "a", RuleInfo{Category: "slur", Weight: 5})
+	assertRuleInfo(t, mod, "abcd", RuleInfo{Category: "mild", Weight: 3})
+	assertRuleInfo(t, mod, "abb*", RuleInfo{Category: "vulgar", Weight: 2, Description: "abbey"})
+	assertRuleInfo(t, mod, "xyz", RuleInfo{Category: "unknown", Weight: 1})
+	assertRuleInfo(t, mod, "missing", RuleInfo{Category: "vulgar", Weight: 2})
+}
+
+func TestLoadRuleInfos(t *testing.T) {
+	tmpFilePath := createTmpRuleInfos(t, `{"Rules": {"abcd": {"Category": "slur"}}}`)
+	defer os.Remove(tmpFilePath)
+
+	mod := NewModSwears()
+	mod.ruleInfoFileName = tmpFilePath
+	err := mod.LoadRuleInfos()
+	if err != Success {
+		t.Fatalf("Expected to load rule infos without errors, got %v", err)
+	}
+	assertRuleInfo(t, mod, "abcd", RuleInfo{Category: "slur", Weight: 5})
+}
+
+func TestLoadRuleInfosErr(t *testing.T) {
+	tmpFilePath := createTmpRuleInfos(t, `{"Rules": [}`)
+	defer os.Remove(tmpFilePath)
+
+	mod := NewModSwears()
+	mod.ruleInfoFileName = tmpFilePath
+	err := mod.LoadRuleInfos()
+	if err != RuleInfoFileReadErr {
+		t.Fatalf("Expected error %v when loading rule infos, got %v", RuleInfoFileReadErr, err)
+	}
+}
+
+func TestSwearEvents(t *testing.T) {
+	tmpFilePath := createTmpDict(t)
+	defer os.Remove(tmpFilePath)
+
+	mod := createSwears(t, tmpFilePath)
+	mod.getRules().ruleInfos.Rules["abb*"] = &RuleInfo{Category: "slur"}
+	matches := mod.FindSwearMatches("a abbey abcd abba")
+	expected := []SwearMatch{
+		SwearMatch{Text: "a", Rule: "a"},
+		SwearMatch{Text: "abbey", Rule: "abb*"},
+		SwearMatch{Text: "abcd", Rule: "abcd"},
+		SwearMatch{Text: "abba", Rule: "abb*"},
+	}
+	if !reflect.DeepEqual(matches, expected) {
+		t.Fatalf("Expected swear matches %#v, got %#v", expected, matches)
+	}
+
+	now := utils.NewLocalDate(2016, 1, 2)
+	events := mod.swearEvents("user1", "channel1", "1.1", now, matches)
+	expectedEvents := []SwearEvent{
+		swearEvent("a", "a", "vulgar", 2),
+		swearEvent("abbey", "abb*", "slur", 5),
+		swearEvent("abcd", "abcd", "vulgar", 2),
+		swearEvent("abba", "abb*", "slur", 5),
+	}
+	for i := range expectedEvents {
+		expectedEvents[i].Time = now
+	}
+	if !reflect.DeepEqual(events, expectedEvents) {
+		t.Fatalf("Expected swear events %#v, got %#v", expectedEvents, events)
+	}
+}
+
+func TestRankByScore(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+
+	mod := createStats(t, tmpFilePath)
+	mod.config.RankSortBy = RankSortByScore
+	mod.getRules().ruleInfos.Rules["m"] = &RuleInfo{Category: "mild"}
+	mod.getRules().ruleInfos.Rules["s"] = &RuleInfo{Category: "slur"}
+	assertAddSwears(t, mod, 1, 2016, "user1", "m", "m", "m")
+	assertAddSwears(t, mod, 1, 2016, "user2", "s")
+	assertAddSwears(t, mod, 2, 2016, "user1", "v")
+
+	expected := []*UserStats{
+		&UserStats{
+			UserId:     "user2",
+			SwearCount: 1,
+			Score:      5,
+			Categories: map[string]int{"slur": 1},
+		},
+		&UserStats{
+			UserId:     "user1",
+			SwearCount: 3,
+			Score:      3,
+			Categories: map[string]int{"mild": 3},
+		},
+	}
+	assertMonthlyRank(t, mod, 1, 2016, expected)
+
+	expected = []*UserStats{
+		&UserStats{
+			UserId:     "user1",
+			SwearCount: 4,
+			Score:      5,
+			Categories: map[string]int{"mild": 3, "vulgar": 1},
+		},
+		&UserStats{
+			UserId:     "user2",
+			SwearCount: 1,
+			Score:      5,
+			Categories: map[string]int{"slur": 1},
+		},
+	}
+	mod.config.RankSortBy = RankSortByCount
+	assertTotalRank(t, mod, expected)
+}
+
+func TestFormatRankLine(t *testing.T) {
+	config := NewModSwearsConfig()
+	config.RankLineFormat = "{index}. {user}: {count}/{score} {category}"
+	userStats := &UserStats{
+		UserId:     "user1",
+		SwearCount: 3,
+		Score:      7,
+		Categories: map[string]int{"mild": 1, "vulgar": 2},
+	}
+	assertFormatRankLine(t, config, userStats, "1. user1: 3/7 vulgar")
+
+	userStats.Categories = map[string]int{"mild": 2, "slur": 2}
+	assertFormatRankLine(t, config, userStats, "1. user1: 3/7 mild")
+
+	userStats.Categories = nil
+	assertFormatRankLine(t, config, userStats, "1. user1: 3/7 vulgar")
+}
+
+func createTmpRuleInfos(t *testing.T, content string) string {
+	fileName := utils.CreateTmpFileName("RuleInfos")
+	if fileName == "" {
+		t.Fatal("Cannot create temp rule infos file path")
+	}
+	err := ioutil.WriteFile(fileName, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fileName
+}
+
+func assertRuleInfo(t *testing.T, mod *ModSwears, rule string, expected RuleInfo) {
+	actual := mod.GetRuleInfo(rule)
+	if actual != expected {
+		t.Fatalf("Expected info %#v for rule '%s', got %#v", expected, rule, actual)
+	}
+}
+
+func swearEvent(text string, rule string, category string, score int) SwearEvent {
+	return SwearEvent{
+		UserId:    "user1",
+		ChannelId: "channel1",
+		Rule:      rule,
+		Text:      text,
+		MessageTs: "1.1",
+		Category:  category,
+		Count:     1,
+		Score:     score,
+	}
+}
+
+func assertAddSwears(t *testing.T, mod *ModSwears, m int, y int, u string, rules ...string) {
+	now := utils.NewLocalDateTime(y, time.Month(m), 2, 12, 0, 0)
+	err := mod.AddSwears(now, u, "channel1", "", swearMatches(rules...))
+	if err != Success {
+		t.Fatalf("Expected no error when adding swears but got %v", err)
+	}
+}
+
+func swearMatches(rules ...string) []SwearMatch {
+	matches := make([]SwearMatch, len(rules))
+	for i, rule := range rules {
+		matches[i] = SwearMatch{Text: rule, Rule: rule}
+	}
+	return matches
+}
+
+func assertFormatRankLine(
+	t *testing.T,
+	config *ModSwearsConfig,
+	userStats *UserStats,
+	expected string) {
+
+	actual := formatRankLine(config, userStats, 1)
+	if actual != expected {
+		t.Fatalf("Expected rank line '%s', got '%s'", expected, actual)
+	}
+}
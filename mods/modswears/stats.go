@@ -2,10 +2,12 @@ package modswears
 
 import (
 	"../../utils"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"sort"
+	"time"
 )
 
 const (
@@ -13,8 +15,17 @@ const (
 	StatsSaveErr     = 12
 )
 
+const (
+	RankSortByCount = "count"
+	RankSortByScore = "score"
+)
+
+// Months are only read from stats files written before events were kept
+// and are imported into Events as synthetic events.
 type AllStats struct {
-	Months map[string]*MonthStats
+	Events  []SwearEvent
+	Months  map[string]*MonthStats `json:",omitempty"`
+	LastSeq int64                  `json:",omitempty"`
 }
 
 type MonthStats struct {
@@ -23,9 +34,13 @@ type MonthStats struct {
 	Users []*UserStats
 }
 
+// Score is the sum of rule weights of counted swears and Categories holds
+// number of swears in every rule category.
 type UserStats struct {
 	UserId     string
 	SwearCount int
+	Score      int            `json:",omitempty"`
+	Categories map[string]int `json:",omitempty"`
 }
 
 type BySwearCount []*UserStats
@@ -38,33 +53,118 @@ func (a BySwearCount) Swap(i, j int) {
 	a[i], a[j] = a[j], a[i]
 }
 
+// Users with equal counts are ordered by score, then by id.
 func (a BySwearCount) Less(i, j int) bool {
-	return a[i].SwearCount > a[j].SwearCount
+	if a[i].SwearCount != a[j].SwearCount {
+		return a[i].SwearCount > a[j].SwearCount
+	}
+	if a[i].Score != a[j].Score {
+		return a[i].Score > a[j].Score
+	}
+	return a[i].UserId < a[j].UserId
+}
+
+type ByScore []*UserStats
+
+func (a ByScore) Len() int {
+	return len(a)
+}
+
+func (a ByScore) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
+}
+
+// Users with equal scores are ordered by count, then by id.
+func (a ByScore) Less(i, j int) bool {
+	if a[i].Score != a[j].Score {
+		return a[i].Score > a[j].Score
+	}
+	if a[i].SwearCount != a[j].SwearCount {
+		return a[i].SwearCount > a[j].SwearCount
+	}
+	return a[i].UserId < a[j].UserId
+}
+
+type ByMonth []*MonthStats
+
+func (a ByMonth) Len() int {
+	return len(a)
+}
+
+func (a ByMonth) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
 }
 
+func (a ByMonth) Less(i, j int) bool {
+	if a[i].Year != a[j].Year {
+		return a[i].Year < a[j].Year
+	}
+	return a[i].Month < a[j].Month
+}
+
+// AddSwearCount counts swears of unknown rules at the first day of the
+// month, each worth a single point.
 func (mod *ModSwears) AddSwearCount(month int, year int, name string, count int) int {
-	stats, err := readStats(mod.statsFileName)
-	if err != Success {
-		return err
+	return mod.stats.Add([]SwearEvent{monthCountEvent(month, year, name, count, count)})
+}
+
+// Returns event counting swears of unknown rules at the first day of the
+// month. Corrections of stats are such events as well.
+func monthCountEvent(month int, year int, userId string, count int, score int) SwearEvent {
+	return SwearEvent{
+		UserId: userId,
+		Time:   utils.NewLocalDate(year, time.Month(month), 1),
+		Count:  count,
+		Score:  score,
 	}
-	addSwearCount(stats, month, year, name, count)
-	return writeStats(mod.statsFileName, stats)
+}
+
+// AddSwears stores an event for every swear found in a message.
+func (mod *ModSwears) AddSwears(
+	now time.Time,
+	userId string,
+	channelId string,
+	messageTs string,
+	swears []SwearMatch) int {
+
+	return mod.stats.Add(mod.swearEvents(userId, channelId, messageTs, now, swears))
 }
 
 func (mod *ModSwears) GetMonthlyRank(month int, year int) ([]*UserStats, int) {
-	stats, err := readStats(mod.statsFileName)
-	if err != Success {
-		return nil, err
-	}
-	return getMonthlyRank(stats, month, year), Success
+	return mod.GetRank(monthQuery(month, year))
 }
 
 func (mod *ModSwears) GetTotalRank() ([]*UserStats, int) {
-	stats, err := readStats(mod.statsFileName)
+	return mod.GetRank(StatsQuery{})
+}
+
+// GetRank sums events matching the query per user and sorts users as set
+// in config.
+func (mod *ModSwears) GetRank(query StatsQuery) ([]*UserStats, int) {
+	events, err := mod.stats.Query(query)
 	if err != Success {
 		return nil, err
 	}
-	return getTotalRank(stats), Success
+	rank := userStatsOf(events)
+	sortRank(rank, mod.config.RankSortBy)
+	return rank, Success
+}
+
+// LoadStats reads stats into memory, applying changes logged since the
+// stats file was last written.
+func (mod *ModSwears) LoadStats() int {
+	return mod.stats.Load()
+}
+
+// Close writes stats kept in memory to the stats file.
+func (mod *ModSwears) Close() {
+	if mod.stopWatch != nil {
+		close(mod.stopWatch)
+		mod.stopWatch = nil
+	}
+	if mod.stats != nil {
+		mod.stats.Close()
+	}
 }
 
 func createStatsFileIfNotExist(fileName string) int {
@@ -84,11 +184,16 @@ func readStats(fileName string) (*AllStats, int) {
 		log.Printf("ModSwears: Cannot read stats from file '%s'\n", fileName)
 		return nil, StatsFileReadErr
 	}
+	fillMissingScores(stats)
+	importMonthStats(stats)
 	return stats, Success
 }
 
 func writeStats(fileName string, stats *AllStats) int {
-	err := utils.JsonToFile(fileName, stats)
+	bytes, err := json.MarshalIndent(stats, "", "    ")
+	if err == nil {
+		err = utils.WriteFileAtomic(fileName, bytes)
+	}
 	if err != nil {
 		log.Printf("ModSwears: Cannot write stats to file '%s'\n", fileName)
 		return StatsSaveErr
@@ -96,67 +201,83 @@ func writeStats(fileName string, stats *AllStats) int {
 	return Success
 }
 
-func addSwearCount(stats *AllStats, month int, year int, userId string, count int) {
-	monthKey := getMonthKey(month, year)
-	monthStats := stats.Months[monthKey]
-	if monthStats == nil {
-		monthStats = &MonthStats{
-			Year:  year,
-			Month: month,
-			Users: []*UserStats{},
-		}
-		stats.Months[monthKey] = monthStats
-	}
-	user := getUserStatsById(monthStats.Users, userId)
-	if user == nil {
-		user = &UserStats{
-			UserId:     userId,
-			SwearCount: 0,
+// Stats written before weighted rules have no score, every swear counted
+// then is worth a single point.
+func fillMissingScores(stats *AllStats) {
+	for _, monthStats := range stats.Months {
+		for _, user := range monthStats.Users {
+			if user.Score == 0 {
+				user.Score = user.SwearCount
+			}
 		}
-		monthStats.Users = append(monthStats.Users, user)
 	}
-	user.SwearCount += count
 }
 
-func getMonthlyRank(stats *AllStats, month int, year int) []*UserStats {
-	monthKey := getMonthKey(month, year)
-	monthStats := stats.Months[monthKey]
-	if monthStats == nil {
-		return []*UserStats{}
+// Replaces monthly stats with synthetic events, oldest month first.
+func importMonthStats(stats *AllStats) {
+	for _, monthKey := range sortedMonthKeys(stats) {
+		stats.Events = append(stats.Events, monthStatsEvents(stats.Months[monthKey])...)
 	}
-	sort.Sort(BySwearCount(monthStats.Users))
-	return monthStats.Users
+	stats.Months = nil
 }
 
-func getTotalRank(stats *AllStats) []*UserStats {
-	userIdToSwears := make(map[string]int)
-	for _, monthStats := range stats.Months {
-		for _, userStats := range monthStats.Users {
-			userId := userStats.UserId
-			swearCount := userIdToSwears[userId]
-			userIdToSwears[userId] = swearCount + userStats.SwearCount
+func addUserStats(user *UserStats, count int, score int, categories map[string]int) {
+	user.SwearCount += count
+	user.Score += score
+	for category, categoryCount := range categories {
+		if user.Categories == nil {
+			user.Categories = map[string]int{}
+		}
+		user.Categories[category] += categoryCount
+		if user.Categories[category] == 0 {
+			delete(user.Categories, category)
 		}
 	}
-	totalRank := toUserStats(userIdToSwears)
-	sort.Sort(BySwearCount(totalRank))
-	return totalRank
+	if len(user.Categories) == 0 {
+		user.Categories = nil
+	}
 }
 
-func toUserStats(userIdToSwears map[string]int) []*UserStats {
+func sortRank(userStats []*UserStats, sortBy string) {
+	if sortBy == RankSortByCount {
+		sort.Stable(BySwearCount(userStats))
+	} else {
+		sort.Stable(ByScore(userStats))
+	}
+}
+
+func toUserStats(userIdToStats map[string]*UserStats) []*UserStats {
 	userStats := []*UserStats{}
-	for userId, swears := range userIdToSwears {
-		userStats = append(userStats, &UserStats{UserId: userId, SwearCount: swears})
+	for _, stats := range userIdToStats {
+		userStats = append(userStats, stats)
 	}
 	return userStats
 }
 
-func getUserStatsById(users []*UserStats, userId string) *UserStats {
-	for _, user := range users {
-		if user.UserId == userId {
-			return user
+// Returns category with the most swears, ties are resolved alphabetically.
+// Empty string is returned if there are no categories.
+func topCategory(categories map[string]int) string {
+	top, topCount := "", 0
+	for category, count := range categories {
+		if count > topCount || (count == topCount && category < top) {
+			top, topCount = category, count
 		}
 	}
-	return nil
+	return top
+}
+
+// Returns keys of months in stats in chronological order.
+func sortedMonthKeys(stats *AllStats) []string {
+	months := []*MonthStats{}
+	for _, monthStats := range stats.Months {
+		months = append(months, monthStats)
+	}
+	sort.Sort(ByMonth(months))
+	keys := make([]string, len(months))
+	for i, monthStats := range months {
+		keys[i] = getMonthKey(monthStats.Month, monthStats.Year)
+	}
+	return keys
 }
 
 func getMonthKey(month int, year int) string {
@@ -165,6 +286,6 @@ func getMonthKey(month int, year int) string {
 
 func newStats() *AllStats {
 	return &AllStats{
-		Months: map[string]*MonthStats{},
+		Events: []SwearEvent{},
 	}
 }
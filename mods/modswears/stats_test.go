@@ -15,9 +15,9 @@ func init() {
 
 func TestAddSwears(t *testing.T) {
 	tmpFilePath := createTmpStatsPath(t)
-	defer os.Remove(tmpFilePath)
+	defer removeStatsFiles(tmpFilePath)
 
-	mod := createStats(tmpFilePath)
+	mod := createStats(t, tmpFilePath)
 	assertAddSwearCount(t, mod, 1, 2016, "user1", 3)
 	assertAddSwearCount(t, mod, 1, 2016, "user1", 2)
 
@@ -25,6 +25,7 @@ func TestAddSwears(t *testing.T) {
 		&UserStats{
 			UserId:     "user1",
 			SwearCount: 5,
+			Score:      5,
 		},
 	}
 
@@ -33,9 +34,9 @@ func TestAddSwears(t *testing.T) {
 
 func TestRankOrder(t *testing.T) {
 	tmpFilePath := createTmpStatsPath(t)
-	defer os.Remove(tmpFilePath)
+	defer removeStatsFiles(tmpFilePath)
 
-	mod := createStats(tmpFilePath)
+	mod := createStats(t, tmpFilePath)
 	assertAddSwearCount(t, mod, 1, 2016, "user1", 3)
 	assertAddSwearCount(t, mod, 1, 2016, "user2", 4)
 	assertAddSwearCount(t, mod, 1, 2016, "user1", 2)
@@ -46,25 +47,41 @@ func TestRankOrder(t *testing.T) {
 		&UserStats{
 			UserId:     "user3",
 			SwearCount: 6,
+			Score:      6,
 		},
 		&UserStats{
 			UserId:     "user1",
 			SwearCount: 5,
+			Score:      5,
 		},
 		&UserStats{
 			UserId:     "user2",
 			SwearCount: 4,
+			Score:      4,
 		},
 	}
 
 	assertMonthlyRank(t, mod, 1, 2016, expected)
 }
 
+func TestRankOrderOfTies(t *testing.T) {
+	rank := []*UserStats{
+		&UserStats{UserId: "user3", SwearCount: 2, Score: 2},
+		&UserStats{UserId: "user2", SwearCount: 2, Score: 4},
+		&UserStats{UserId: "user1", SwearCount: 2, Score: 2},
+		&UserStats{UserId: "user4", SwearCount: 1, Score: 4},
+	}
+	sortRank(rank, RankSortByCount)
+	assertRankUserIds(t, rank, "user2", "user1", "user3", "user4")
+	sortRank(rank, RankSortByScore)
+	assertRankUserIds(t, rank, "user2", "user4", "user1", "user3")
+}
+
 func TestUnknownMonth(t *testing.T) {
 	tmpFilePath := createTmpStatsPath(t)
-	defer os.Remove(tmpFilePath)
+	defer removeStatsFiles(tmpFilePath)
 
-	mod := createStats(tmpFilePath)
+	mod := createStats(t, tmpFilePath)
 	assertAddSwearCount(t, mod, 1, 2016, "user1", 1)
 
 	assertMonthlyRank(t, mod, 2, 2016, []*UserStats{})
@@ -72,9 +89,9 @@ func TestUnknownMonth(t *testing.T) {
 
 func TestTotalRank(t *testing.T) {
 	tmpFilePath := createTmpStatsPath(t)
-	defer os.Remove(tmpFilePath)
+	defer removeStatsFiles(tmpFilePath)
 
-	mod := createStats(tmpFilePath)
+	mod := createStats(t, tmpFilePath)
 	assertAddSwearCount(t, mod, 1, 2016, "user1", 1)
 	assertAddSwearCount(t, mod, 1, 2016, "user2", 1)
 	assertAddSwearCount(t, mod, 2, 2016, "user1", 2)
@@ -86,14 +103,17 @@ func TestTotalRank(t *testing.T) {
 		&UserStats{
 			UserId:     "user2",
 			SwearCount: 5,
+			Score:      5,
 		},
 		&UserStats{
 			UserId:     "user1",
 			SwearCount: 4,
+			Score:      4,
 		},
 		&UserStats{
 			UserId:     "user3",
 			SwearCount: 3,
+			Score:      3,
 		},
 	}
 
@@ -102,9 +122,9 @@ func TestTotalRank(t *testing.T) {
 
 func TestEmptyTotalRank(t *testing.T) {
 	tmpFilePath := createTmpStatsPath(t)
-	defer os.Remove(tmpFilePath)
+	defer removeStatsFiles(tmpFilePath)
 
-	mod := createStats(tmpFilePath)
+	mod := createStats(t, tmpFilePath)
 	assertTotalRank(t, mod, []*UserStats{})
 }
 
@@ -116,12 +136,23 @@ func createTmpStatsPath(t *testing.T) string {
 	return fileName
 }
 
-func createStats(tmpFilePath string) *ModSwears {
+func createStats(t *testing.T, tmpFilePath string) *ModSwears {
 	mod := NewModSwears()
 	mod.statsFileName = tmpFilePath
+	mod.statsLogFileName = tmpFilePath + ".log"
+	mod.stats = newJsonStatsStore(tmpFilePath, tmpFilePath+".log", mod.config.StatsCompactLogSize)
+	err := mod.LoadStats()
+	if err != Success {
+		t.Fatalf("Expected to load stats without errors, got %v", err)
+	}
 	return mod
 }
 
+func removeStatsFiles(tmpFilePath string) {
+	os.Remove(tmpFilePath)
+	os.Remove(tmpFilePath + ".log")
+}
+
 func assertAddSwearCount(t *testing.T, mod *ModSwears, m int, y int, u string, n int) {
 	err := mod.AddSwearCount(m, y, u, n)
 	if err != Success {
@@ -148,3 +179,13 @@ func assertTotalRank(t *testing.T, mod *ModSwears, expected []*UserStats) {
 		t.Fatal("Total rank deep equal failed")
 	}
 }
+
+func assertRankUserIds(t *testing.T, rank []*UserStats, expected ...string) {
+	actual := make([]string, len(rank))
+	for i, userStats := range rank {
+		actual[i] = userStats.UserId
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected rank %v, got %v", expected, actual)
+	}
+}
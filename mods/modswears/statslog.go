@@ -0,0 +1,232 @@
+package modswears
+
+import (
+	"../../utils"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Stats change appended to the stats log. Records with sequence number
+// not greater than AllStats.LastSeq are already in the stats file. Records
+// written before events were kept add Count swears with Score and
+// Categories to monthly stats of the user instead of Events.
+type statsRecord struct {
+	Seq        int64
+	Events     []SwearEvent   `json:",omitempty"`
+	Month      int            `json:",omitempty"`
+	Year       int            `json:",omitempty"`
+	UserId     string         `json:",omitempty"`
+	Count      int            `json:",omitempty"`
+	Score      int            `json:",omitempty"`
+	Categories map[string]int `json:",omitempty"`
+}
+
+// Stats kept in memory. Every change is appended to the log file before it
+// is applied and the log is compacted into the stats file once it grows to
+// compactSize records, on flush and on close. Events of every month are
+// kept encoded for the stats file, only months changed since the last
+// compaction are encoded again.
+type jsonStatsStore struct {
+	mutex       sync.Mutex
+	fileName    string
+	logFileName string
+	compactSize int
+	index       *eventIndex
+	lastSeq     int64
+	encoded     map[int][]byte
+	log         *os.File
+	logSize     int
+}
+
+func newJsonStatsStore(fileName string, logFileName string, compactSize int) *jsonStatsStore {
+	return &jsonStatsStore{
+		fileName:    fileName,
+		logFileName: logFileName,
+		compactSize: compactSize,
+	}
+}
+
+// Reads the stats file, replays the log and compacts it.
+func (s *jsonStatsStore) Load() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	stats, err := readStats(s.fileName)
+	if err != Success {
+		return err
+	}
+	err = replayStatsLog(s.logFileName, stats)
+	if err != Success {
+		return err
+	}
+	if s.log != nil {
+		s.log.Close()
+	}
+	logFile, openErr := os.OpenFile(s.logFileName, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if openErr != nil {
+		log.Printf("ModSwears: Cannot open stats log file '%s': %v\n", s.logFileName, openErr)
+		return StatsFileReadErr
+	}
+	s.index = newEventIndex()
+	s.index.add(stats.Events...)
+	s.lastSeq = stats.LastSeq
+	s.encoded = map[int][]byte{}
+	s.log = logFile
+	return s.compact()
+}
+
+func (s *jsonStatsStore) Add(events []SwearEvent) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	record := statsRecord{
+		Seq:    s.lastSeq + 1,
+		Events: events,
+	}
+	bytes, _ := json.Marshal(record)
+	_, err := s.log.Write(append(bytes, '\n'))
+	if err != nil {
+		log.Printf("ModSwears: Cannot append to stats log file '%s': %v\n", s.logFileName, err)
+		return StatsSaveErr
+	}
+	s.index.add(events...)
+	for _, event := range events {
+		delete(s.encoded, eventMonthKey(event.Time))
+	}
+	s.lastSeq = record.Seq
+	s.logSize++
+	if s.logSize >= s.compactSize {
+		s.compact()
+	}
+	return Success
+}
+
+func (s *jsonStatsStore) Query(query StatsQuery) ([]SwearEvent, int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.index.query(query), Success
+}
+
+// Compacts the log if anything was added since the last compaction.
+func (s *jsonStatsStore) Flush() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.logSize == 0 {
+		return Success
+	}
+	return s.compact()
+}
+
+func (s *jsonStatsStore) Close() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	err := Success
+	if s.logSize > 0 {
+		err = s.compact()
+	}
+	if s.log != nil {
+		s.log.Close()
+		s.log = nil
+	}
+	return err
+}
+
+// Writes stats from memory to the stats file and empties the log. Must be
+// called with mutex held.
+func (s *jsonStatsStore) compact() int {
+	var buffer bytes.Buffer
+	buffer.WriteString("{\"Events\": [")
+	for i, month := range s.index.monthKeys() {
+		if _, ok := s.encoded[month]; !ok {
+			s.encoded[month] = encodeEvents(s.index.monthEvents(month))
+		}
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+		buffer.Write(s.encoded[month])
+	}
+	buffer.WriteString("\n], \"LastSeq\": ")
+	buffer.WriteString(strconv.FormatInt(s.lastSeq, 10))
+	buffer.WriteString("}\n")
+	saveErr := utils.WriteFileAtomic(s.fileName, buffer.Bytes())
+	if saveErr != nil {
+		log.Printf("ModSwears: Cannot write stats to file '%s'\n", s.fileName)
+		return StatsSaveErr
+	}
+	if s.log != nil {
+		truncateErr := s.log.Truncate(0)
+		if truncateErr != nil {
+			log.Printf("ModSwears: Cannot truncate stats log file '%s': %v\n", s.logFileName, truncateErr)
+		}
+	}
+	s.logSize = 0
+	return Success
+}
+
+// Encodes events as a part of the JSON array of events in the stats file,
+// an event per line.
+func encodeEvents(events []SwearEvent) []byte {
+	var buffer bytes.Buffer
+	for i, event := range events {
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+		bytes, _ := json.Marshal(event)
+		buffer.WriteString("\n")
+		buffer.Write(bytes)
+	}
+	return buffer.Bytes()
+}
+
+// Applies records not yet in stats. Reading stops at the first broken
+// record, which can only be the last one, written partially.
+func replayStatsLog(fileName string, stats *AllStats) int {
+	file, err := os.Open(fileName)
+	if os.IsNotExist(err) {
+		return Success
+	}
+	if err != nil {
+		log.Printf("ModSwears: Cannot open stats log file '%s': %v\n", fileName, err)
+		return StatsFileReadErr
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		record := statsRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			log.Printf("ModSwears: Skipping broken stats log record '%s'\n", scanner.Text())
+			break
+		}
+		if record.Seq > stats.LastSeq {
+			applyStatsRecord(stats, record)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("ModSwears: Cannot read stats log file '%s': %v\n", fileName, err)
+		return StatsFileReadErr
+	}
+	return Success
+}
+
+func applyStatsRecord(stats *AllStats, record statsRecord) {
+	if len(record.Events) == 0 && record.Count != 0 {
+		monthStats := &MonthStats{
+			Month: record.Month,
+			Year:  record.Year,
+			Users: []*UserStats{
+				&UserStats{
+					UserId:     record.UserId,
+					SwearCount: record.Count,
+					Score:      record.Score,
+					Categories: record.Categories,
+				},
+			},
+		}
+		record.Events = monthStatsEvents(monthStats)
+	}
+	stats.Events = append(stats.Events, record.Events...)
+	stats.LastSeq = record.Seq
+}
@@ -0,0 +1,204 @@
+package modswears
+
+import (
+	"../../utils"
+	"io/ioutil"
+	"testing"
+)
+
+func TestStatsLogReplay(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+
+	mod := createStats(t, tmpFilePath)
+	assertAddSwearCount(t, mod, 1, 2016, "user1", 3)
+	assertAddSwearCount(t, mod, 1, 2016, "user2", 1)
+	assertStatsLogSize(t, tmpFilePath, 2)
+
+	mod = createStats(t, tmpFilePath)
+	assertStatsLogSize(t, tmpFilePath, 0)
+	expected := []*UserStats{
+		&UserStats{UserId: "user1", SwearCount: 3, Score: 3},
+		&UserStats{UserId: "user2", SwearCount: 1, Score: 1},
+	}
+	assertMonthlyRank(t, mod, 1, 2016, expected)
+}
+
+func TestStatsLogSkipsCompactedRecords(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+
+	stats := newStats()
+	stats.LastSeq = 2
+	stats.Events = monthStatsEvents(&MonthStats{
+		Month: 1,
+		Year:  2016,
+		Users: []*UserStats{&UserStats{UserId: "user1", SwearCount: 2, Score: 2}},
+	})
+	writeStats(tmpFilePath, stats)
+	writeStatsLog(t, tmpFilePath,
+		`{"Seq":1,"Month":1,"Year":2016,"UserId":"user1","Count":1,"Score":1}`,
+		`{"Seq":2,"Month":1,"Year":2016,"UserId":"user1","Count":1,"Score":1}`,
+		`{"Seq":3,"Month":1,"Year":2016,"UserId":"user1","Count":4,"Score":5}`)
+
+	mod := createStats(t, tmpFilePath)
+	expected := []*UserStats{
+		&UserStats{UserId: "user1", SwearCount: 6, Score: 7},
+	}
+	assertMonthlyRank(t, mod, 1, 2016, expected)
+}
+
+func TestStatsLogBrokenRecord(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+
+	writeStatsLog(t, tmpFilePath,
+		`{"Seq":1,"Month":1,"Year":2016,"UserId":"user1","Count":1,"Score":1}`,
+		`{"Seq":2,"Month":1,"Ye`)
+
+	mod := createStats(t, tmpFilePath)
+	expected := []*UserStats{
+		&UserStats{UserId: "user1", SwearCount: 1, Score: 1},
+	}
+	assertMonthlyRank(t, mod, 1, 2016, expected)
+	assertAddSwearCount(t, mod, 1, 2016, "user1", 1)
+
+	mod = createStats(t, tmpFilePath)
+	expected[0] = &UserStats{UserId: "user1", SwearCount: 2, Score: 2}
+	assertMonthlyRank(t, mod, 1, 2016, expected)
+}
+
+func TestStatsCompaction(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+
+	mod := createStats(t, tmpFilePath)
+	mod.stats.(*jsonStatsStore).compactSize = 2
+	assertAddSwearCount(t, mod, 1, 2016, "user1", 1)
+	assertStatsLogSize(t, tmpFilePath, 1)
+	assertAddSwearCount(t, mod, 1, 2016, "user1", 1)
+	assertStatsLogSize(t, tmpFilePath, 0)
+	assertAddSwearCount(t, mod, 1, 2016, "user1", 1)
+	assertStatsLogSize(t, tmpFilePath, 1)
+
+	stats, _ := readStats(tmpFilePath)
+	if stats.LastSeq != 2 || len(stats.Events) != 2 {
+		t.Fatalf("Expected compacted stats file with last sequence 2, got %v", stats.LastSeq)
+	}
+}
+
+func TestStatsCompactionEncodesChangedMonths(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+
+	mod := createStats(t, tmpFilePath)
+	store := mod.stats.(*jsonStatsStore)
+	assertAddSwearCount(t, mod, 1, 2016, "user1", 1)
+	assertAddSwearCount(t, mod, 2, 2016, "user1", 1)
+	store.Flush()
+	january := eventMonthKey(utils.NewLocalDate(2016, 1, 1))
+	encoded := store.encoded[january]
+	assertAddSwearCount(t, mod, 2, 2016, "user2", 1)
+	if _, ok := store.encoded[january+1]; ok || january+1 != eventMonthKey(utils.NewLocalDate(2016, 2, 1)) {
+		t.Fatal("Expected changed month to be encoded again on compaction")
+	}
+	store.Flush()
+	if &store.encoded[january][0] != &encoded[0] {
+		t.Fatal("Expected unchanged month to be written without encoding it again")
+	}
+
+	stats, _ := readStats(tmpFilePath)
+	if stats.LastSeq != 3 || len(stats.Events) != 3 {
+		t.Fatalf("Expected stats file with 3 events, got %#v", stats)
+	}
+}
+
+func TestStatsClose(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+
+	mod := createStats(t, tmpFilePath)
+	assertAddSwearCount(t, mod, 1, 2016, "user1", 1)
+	mod.Close()
+	assertStatsLogSize(t, tmpFilePath, 0)
+	if err := mod.AddSwearCount(1, 2016, "user1", 1); err != StatsSaveErr {
+		t.Fatalf("Expected error %v when adding swears to closed stats, got %v", StatsSaveErr, err)
+	}
+
+	mod = createStats(t, tmpFilePath)
+	expected := []*UserStats{
+		&UserStats{UserId: "user1", SwearCount: 1, Score: 1},
+	}
+	assertMonthlyRank(t, mod, 1, 2016, expected)
+}
+
+func TestRankIsCopy(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+
+	mod := createStats(t, tmpFilePath)
+	assertAddSwearCount(t, mod, 1, 2016, "user1", 1)
+	rank, _ := mod.GetMonthlyRank(1, 2016)
+	rank[0].UserId = "name"
+	rank, _ = mod.GetTotalRank()
+	rank[0].UserId = "name"
+
+	expected := []*UserStats{
+		&UserStats{UserId: "user1", SwearCount: 1, Score: 1},
+	}
+	assertMonthlyRank(t, mod, 1, 2016, expected)
+	assertTotalRank(t, mod, expected)
+}
+
+func TestImportMonthStats(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+
+	content := `{"Months":{"1.2016":{"Year":2016,"Month":1,"Users":[` +
+		`{"UserId":"user1","SwearCount":3},` +
+		`{"UserId":"user2","SwearCount":2,"Score":7,"Categories":{"mild":1,"slur":1}}]}}}`
+	if err := ioutil.WriteFile(tmpFilePath, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+	mod := createStats(t, tmpFilePath)
+	assertAddSwears(t, mod, 1, 2016, "user1", "v")
+
+	mod = createStats(t, tmpFilePath)
+	mod.config.RankSortBy = RankSortByScore
+	expected := []*UserStats{
+		&UserStats{UserId: "user2", SwearCount: 2, Score: 7, Categories: map[string]int{"mild": 1, "slur": 1}},
+		&UserStats{UserId: "user1", SwearCount: 4, Score: 5, Categories: map[string]int{"vulgar": 1}},
+	}
+	assertMonthlyRank(t, mod, 1, 2016, expected)
+	stats, _ := readStats(tmpFilePath)
+	if stats.Months != nil || len(stats.Events) != 4 {
+		t.Fatalf("Expected monthly stats replaced with 4 events, got %#v", stats)
+	}
+}
+
+func writeStatsLog(t *testing.T, tmpFilePath string, records ...string) {
+	content := ""
+	for _, record := range records {
+		content += record + "\n"
+	}
+	err := ioutil.WriteFile(tmpFilePath+".log", []byte(content), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertStatsLogSize(t *testing.T, tmpFilePath string, expected int) {
+	content, err := ioutil.ReadFile(tmpFilePath + ".log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := 0
+	for _, b := range content {
+		if b == '\n' {
+			actual++
+		}
+	}
+	if actual != expected {
+		t.Fatalf("Expected %d stats log records, got %d", expected, actual)
+	}
+}
@@ -0,0 +1,226 @@
+package modswears
+
+import (
+	"../../utils"
+	"log"
+	"sort"
+	"time"
+)
+
+const (
+	StatsMigrationErr = 14
+)
+
+const (
+	StatsBackendJson = "json"
+	StatsBackendKv   = "kv"
+)
+
+// StatsStore keeps swear events. Ranks and other stats are computed from
+// events returned by Query.
+type StatsStore interface {
+	Load() int
+	Add(events []SwearEvent) int
+	Query(query StatsQuery) ([]SwearEvent, int)
+	Flush() int
+	Close() int
+}
+
+// Swears found in a message. Every detection is a single event with count
+// one, a detection reversed after the message is edited or deleted is an
+// event with count minus one. Corrections by admins reverse or move
+// swears of the same kind in a single event marked as a correction, so
+// edits of the message do not take them for detections. Events imported
+// from monthly stats have no rule, text or message, they count all swears
+// of the user in a category during the month and happen at the first day
+// of the month.
+type SwearEvent struct {
+	UserId     string
+	ChannelId  string `json:",omitempty"`
+	Time       time.Time
+	Rule       string `json:",omitempty"`
+	Text       string `json:",omitempty"`
+	MessageTs  string `json:",omitempty"`
+	Category   string `json:",omitempty"`
+	Count      int
+	Score      int
+	Correction bool `json:",omitempty"`
+}
+
+// Events which happened in [From, To) and match all non-empty filters.
+// Zero From or To leaves the period open.
+type StatsQuery struct {
+	From      time.Time
+	To        time.Time
+	UserId    string
+	ChannelId string
+	Rule      string
+	MessageTs string
+}
+
+func monthQuery(month int, year int) StatsQuery {
+	return monthQueryIn(month, year, time.Local)
+}
+
+// Events of the month as it starts and ends in the location.
+func monthQueryIn(month int, year int, location *time.Location) StatsQuery {
+	from := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, location)
+	return StatsQuery{
+		From: from,
+		To:   from.AddDate(0, 1, 0),
+	}
+}
+
+func (query StatsQuery) matches(event SwearEvent) bool {
+	if !query.From.IsZero() && event.Time.Before(query.From) {
+		return false
+	}
+	if !query.To.IsZero() && !event.Time.Before(query.To) {
+		return false
+	}
+	return (query.UserId == "" || query.UserId == event.UserId) &&
+		(query.ChannelId == "" || query.ChannelId == event.ChannelId) &&
+		(query.Rule == "" || query.Rule == event.Rule) &&
+		(query.MessageTs == "" || query.MessageTs == event.MessageTs)
+}
+
+func newStatsStore(
+	backend string,
+	fileName string,
+	logFileName string,
+	dbFileName string,
+	compactSize int) StatsStore {
+
+	if backend == StatsBackendKv {
+		return newKvStatsStore(dbFileName)
+	}
+	return newJsonStatsStore(fileName, logFileName, compactSize)
+}
+
+// Returns an event for every swear, scored and categorized by its rule.
+func (mod *ModSwears) swearEvents(
+	userId string,
+	channelId string,
+	messageTs string,
+	now time.Time,
+	swears []SwearMatch) []SwearEvent {
+
+	events := make([]SwearEvent, len(swears))
+	for i, swear := range swears {
+		info := mod.GetRuleInfo(swear.Rule)
+		events[i] = SwearEvent{
+			UserId:    userId,
+			ChannelId: channelId,
+			Time:      now,
+			Rule:      swear.Rule,
+			Text:      swear.Text,
+			MessageTs: messageTs,
+			Category:  info.Category,
+			Count:     1,
+			Score:     info.Weight,
+		}
+	}
+	return events
+}
+
+// Sums events per user. Users whose swears have all been reversed are left
+// out.
+func userStatsOf(events []SwearEvent) []*UserStats {
+	userIdToStats := make(map[string]*UserStats)
+	for _, event := range events {
+		user := userIdToStats[event.UserId]
+		if user == nil {
+			user = &UserStats{UserId: event.UserId}
+			userIdToStats[event.UserId] = user
+		}
+		var categories map[string]int
+		if event.Category != "" {
+			categories = map[string]int{event.Category: event.Count}
+		}
+		addUserStats(user, event.Count, event.Score, categories)
+	}
+	for userId, user := range userIdToStats {
+		if user.SwearCount == 0 {
+			delete(userIdToStats, userId)
+		}
+	}
+	return toUserStats(userIdToStats)
+}
+
+// MigrateStats copies events from the stats file and its log into the
+// key-value store, which has to be empty. Returns number of copied events.
+func (mod *ModSwears) MigrateStats() (int, int) {
+	target, ok := mod.stats.(*kvStatsStore)
+	if !ok || !target.isEmpty() {
+		log.Println("ModSwears: stats can only be migrated into an empty key-value store")
+		return 0, StatsMigrationErr
+	}
+	source := newJsonStatsStore(
+		mod.statsFileName,
+		mod.statsLogFileName,
+		mod.config.StatsCompactLogSize)
+	err := source.Load()
+	if err != Success {
+		return 0, err
+	}
+	defer source.Close()
+	events, err := source.Query(StatsQuery{})
+	if err != Success {
+		return 0, err
+	}
+	err = target.Add(events)
+	if err != Success {
+		return 0, err
+	}
+	return len(events), Success
+}
+
+func (mod *ModSwears) flushStatsPeriodically(interval time.Duration) {
+	for range time.Tick(interval) {
+		mod.stats.Flush()
+	}
+}
+
+// Converts monthly stats of users into synthetic events, one per category.
+func monthStatsEvents(monthStats *MonthStats) []SwearEvent {
+	events := []SwearEvent{}
+	start := utils.NewLocalDate(monthStats.Year, time.Month(monthStats.Month), 1)
+	for _, user := range monthStats.Users {
+		for _, event := range splitScore(user) {
+			event.UserId = user.UserId
+			event.Time = start
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// Splits score of aggregated stats between categories in proportion to
+// swear counts, the last category gets what is left after rounding.
+func splitScore(user *UserStats) []SwearEvent {
+	if len(user.Categories) == 0 {
+		return []SwearEvent{
+			SwearEvent{Count: user.SwearCount, Score: user.Score},
+		}
+	}
+	categories := []string{}
+	for category := range user.Categories {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	events := make([]SwearEvent, len(categories))
+	total, scoreLeft := 0, user.Score
+	for _, category := range categories {
+		total += user.Categories[category]
+	}
+	for i, category := range categories {
+		count := user.Categories[category]
+		score := scoreLeft
+		if i < len(categories)-1 && total > 0 {
+			score = user.Score * count / total
+		}
+		scoreLeft -= score
+		events[i] = SwearEvent{Category: category, Count: count, Score: score}
+	}
+	return events
+}
@@ -0,0 +1,106 @@
+package modswears
+
+import (
+	"../../mods"
+	"github.com/nlopes/slack"
+	"os"
+	"testing"
+)
+
+func TestJsonStatsQuery(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+
+	mod := createStats(t, tmpFilePath)
+	assertAddSwears(t, mod, 1, 2016, "user1", "a", "b")
+	assertAddSwears(t, mod, 2, 2016, "user1", "a")
+	assertAddSwearCount(t, mod, 1, 2016, "user2", 1)
+
+	mod = createStats(t, tmpFilePath)
+	assertStatsQuery(t, mod, StatsQuery{Rule: "a"}, []*UserStats{
+		&UserStats{UserId: "user1", SwearCount: 2, Score: 4, Categories: map[string]int{"vulgar": 2}},
+	})
+	query := monthQuery(1, 2016)
+	query.ChannelId = "channel1"
+	assertStatsQuery(t, mod, query, []*UserStats{
+		&UserStats{UserId: "user1", SwearCount: 2, Score: 4, Categories: map[string]int{"vulgar": 2}},
+	})
+}
+
+func TestMigrateStats(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+	tmpDbPath := createTmpStatsPath(t)
+	defer os.Remove(tmpDbPath)
+
+	mod := createStats(t, tmpFilePath)
+	mod.getRules().ruleInfos.Rules["m"] = &RuleInfo{Category: "mild"}
+	assertAddSwears(t, mod, 1, 2016, "user1", "m", "v", "v")
+	assertAddSwearCount(t, mod, 2, 2016, "user2", 2)
+	mod.Close()
+
+	mod.stats = newKvStatsStore(tmpDbPath)
+	mod.LoadStats()
+	assertMigrateStats(t, mod, 4)
+	assertMonthlyRank(t, mod, 1, 2016, []*UserStats{
+		&UserStats{UserId: "user1", SwearCount: 3, Score: 5, Categories: map[string]int{"mild": 1, "vulgar": 2}},
+	})
+	assertMonthlyRank(t, mod, 2, 2016, []*UserStats{
+		&UserStats{UserId: "user2", SwearCount: 2, Score: 2},
+	})
+
+	if _, err := mod.MigrateStats(); err != StatsMigrationErr {
+		t.Fatalf("Expected error %v when migrating into non-empty store, got %v", StatsMigrationErr, err)
+	}
+}
+
+func TestMigrateStatsIntoJson(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+
+	mod := createStats(t, tmpFilePath)
+	if _, err := mod.MigrateStats(); err != StatsMigrationErr {
+		t.Fatalf("Expected error %v when migrating into stats file, got %v", StatsMigrationErr, err)
+	}
+}
+
+func TestMigrateStatsNotAdmin(t *testing.T) {
+	tmpDbPath := createTmpStatsPath(t)
+	defer os.Remove(tmpDbPath)
+
+	mod := NewModSwears()
+	mod.state = mods.NewState(nil, make(chan mods.Response))
+	mod.state.Users().SetUsers([]slack.User{slack.User{ID: "U1", Name: "john"}})
+	mod.stats = newKvStatsStore(tmpDbPath)
+	mod.LoadStats()
+	defer mod.Close()
+	if response := mod.migrateStats("U1"); response != mod.config.OnNotAdminErr {
+		t.Fatalf("Expected migration refused to user who is not admin, got '%s'", response)
+	}
+}
+
+func TestSplitScore(t *testing.T) {
+	user := &UserStats{
+		SwearCount: 4,
+		Score:      10,
+		Categories: map[string]int{"mild": 1, "slur": 1, "vulgar": 2},
+	}
+	counts := splitScore(user)
+	score := 0
+	for _, count := range counts {
+		score += count.Score
+	}
+	if len(counts) != 3 || counts[0].Category != "mild" || counts[0].Score != 2 || score != 10 {
+		t.Fatalf("Expected score 10 split between 3 categories, got %#v", counts)
+	}
+}
+
+func assertMigrateStats(t *testing.T, mod *ModSwears, expected int) {
+	actual, err := mod.MigrateStats()
+	if err != Success {
+		t.Fatalf("Expected no error when migrating stats but got %v", err)
+	}
+	if actual != expected {
+		t.Fatalf("Expected %d migrated records, got %d", expected, actual)
+	}
+}
@@ -0,0 +1,119 @@
+package modswears
+
+import (
+	"../../dictmatch"
+	"log"
+	"os"
+	"time"
+)
+
+// Everything needed to find swears in a message. Rules published by
+// setRules are never modified, so messages can be processed while rules
+// change. Writers hold rulesMutex, modify a clone and publish it.
+type swearRules struct {
+	config     *ModSwearsConfig
+	dict       *dictmatch.Dict
+	normalizer *wordNormalizer
+	tokenizer  *messageTokenizer
+	regexRules []*regexRule
+	lemmas     map[string][]string
+	lemmaForms map[string]string
+	ruleInfos  *RuleInfos
+}
+
+func (mod *ModSwears) getRules() *swearRules {
+	return mod.rules.Load().(*swearRules)
+}
+
+func (mod *ModSwears) setRules(rules *swearRules) {
+	mod.rules.Store(rules)
+}
+
+// ReloadRules reads the dictionary and rule infos again. Current rules are
+// kept if either of them cannot be read.
+func (mod *ModSwears) ReloadRules() int {
+	err := mod.LoadSwears()
+	if err != Success {
+		return err
+	}
+	return mod.LoadRuleInfos()
+}
+
+// Polls the dictionary file and reloads swears when it was changed by
+// something other than the bot itself, until stop is closed.
+func (mod *ModSwears) watchDictFile(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			mod.reloadChangedDict()
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (mod *ModSwears) reloadChangedDict() bool {
+	modTime, ok := getModTime(mod.dictFileName)
+	mod.rulesMutex.Lock()
+	changed := ok && !modTime.Equal(mod.dictModTime)
+	mod.rulesMutex.Unlock()
+	if !changed {
+		return false
+	}
+	log.Printf("ModSwears: swear dictionary file '%s' changed, reloading\n", mod.dictFileName)
+	return mod.LoadSwears() == Success
+}
+
+// Remembers modification time of the dictionary file written or read by
+// the bot. Must be called with rulesMutex held.
+func (mod *ModSwears) updateDictModTime() {
+	if modTime, ok := getModTime(mod.dictFileName); ok {
+		mod.dictModTime = modTime
+	}
+}
+
+func getModTime(fileName string) (time.Time, bool) {
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return info.ModTime(), true
+}
+
+func (rules *swearRules) clone() *swearRules {
+	regexRules := make([]*regexRule, len(rules.regexRules))
+	copy(regexRules, rules.regexRules)
+	lemmas := make(map[string][]string, len(rules.lemmas))
+	for rule, forms := range rules.lemmas {
+		lemmas[rule] = forms
+	}
+	lemmaForms := make(map[string]string, len(rules.lemmaForms))
+	for form, rule := range rules.lemmaForms {
+		lemmaForms[form] = rule
+	}
+	return &swearRules{
+		config:     rules.config,
+		dict:       rules.dict.Clone(),
+		normalizer: rules.normalizer,
+		tokenizer:  rules.tokenizer,
+		regexRules: regexRules,
+		lemmas:     lemmas,
+		lemmaForms: lemmaForms,
+		ruleInfos:  rules.ruleInfos,
+	}
+}
+
+func newSwearRules(config *ModSwearsConfig, ruleInfos *RuleInfos) *swearRules {
+	return &swearRules{
+		config:     config,
+		dict:       dictmatch.NewDictMode(dictMode(config)),
+		normalizer: newWordNormalizer(config),
+		tokenizer:  newMessageTokenizer(config),
+		regexRules: []*regexRule{},
+		lemmas:     map[string][]string{},
+		lemmaForms: map[string]string{},
+		ruleInfos:  ruleInfos,
+	}
+}
@@ -0,0 +1,130 @@
+package modswears
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReloadRules(t *testing.T) {
+	tmpFilePath := createTmpDict(t)
+	defer os.Remove(tmpFilePath)
+
+	mod := createSwears(t, tmpFilePath)
+	mod.ruleInfoFileName = createTmpRuleInfos(t, `{"Rules": {"xyz": {"Category": "slur"}}}`)
+	defer os.Remove(mod.ruleInfoFileName)
+	appendDictRule(t, tmpFilePath, "xyz")
+	assertFindSwears(t, mod, "xyz abcd", []string{"abcd"})
+
+	err := mod.ReloadRules()
+	if err != Success {
+		t.Fatalf("Expected no errors when reloading rules, got %v", err)
+	}
+	assertFindSwears(t, mod, "xyz abcd", []string{"xyz", "abcd"})
+	assertRuleInfo(t, mod, "xyz", RuleInfo{Category: "slur", Weight: 5})
+}
+
+func TestReloadRulesKeepsRulesOnErr(t *testing.T) {
+	tmpFilePath := createTmpDict(t)
+	mod := createSwears(t, tmpFilePath)
+	os.Remove(tmpFilePath)
+
+	err := mod.ReloadRules()
+	if err != DictFileReadErr {
+		t.Fatalf("Expected error %v when reloading rules, got %v", DictFileReadErr, err)
+	}
+	assertFindSwears(t, mod, "abcd", []string{"abcd"})
+}
+
+func TestReloadChangedDict(t *testing.T) {
+	tmpFilePath := createTmpDict(t)
+	defer os.Remove(tmpFilePath)
+
+	mod := createSwears(t, tmpFilePath)
+	assertReloadChangedDict(t, mod, false)
+	assertAddRule(t, mod, "xyz")
+	assertReloadChangedDict(t, mod, false)
+
+	appendDictRule(t, tmpFilePath, "klm")
+	modTime := time.Now().Add(time.Minute)
+	os.Chtimes(tmpFilePath, modTime, modTime)
+	assertReloadChangedDict(t, mod, true)
+	assertReloadChangedDict(t, mod, false)
+	assertFindSwears(t, mod, "klm xyz", []string{"klm", "xyz"})
+}
+
+func TestRuleCount(t *testing.T) {
+	tmpFilePath := createTmpDict(t)
+	defer os.Remove(tmpFilePath)
+
+	mod := createSwears(t, tmpFilePath)
+	assertAddRule(t, mod, "+dupa")
+	assertAddRule(t, mod, "/xy?zz/")
+	assertAddRule(t, mod, "xy z*")
+	assertAddRule(t, mod, "!abba")
+	if count := mod.RuleCount(); count != 7 || count != len(mod.ListRules("")) {
+		t.Fatalf("Expected 7 rules, got %d, listed %v", count, mod.ListRules(""))
+	}
+}
+
+func TestWatchDictFileStops(t *testing.T) {
+	tmpFilePath := createTmpDict(t)
+	defer os.Remove(tmpFilePath)
+
+	mod := createSwears(t, tmpFilePath)
+	stop := make(chan struct{})
+	mod.stopWatch = stop
+	stopped := make(chan bool)
+	go func() {
+		mod.watchDictFile(time.Millisecond, stop)
+		stopped <- true
+	}()
+	mod.Close()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Expected watching dictionary file to stop on close")
+	}
+}
+
+func TestConcurrentRuleChanges(t *testing.T) {
+	tmpFilePath := createTmpDict(t)
+	defer os.Remove(tmpFilePath)
+
+	mod := createSwears(t, tmpFilePath)
+	var wait sync.WaitGroup
+	wait.Add(2)
+	go func() {
+		defer wait.Done()
+		for i := 0; i < 20; i++ {
+			mod.AddRule(fmt.Sprintf("~x%dy", i))
+			mod.LoadSwears()
+		}
+	}()
+	go func() {
+		defer wait.Done()
+		for i := 0; i < 200; i++ {
+			mod.FindSwears("a abcd abbey x1y")
+		}
+	}()
+	wait.Wait()
+	assertFindSwears(t, mod, "x19y", []string{"x19y"})
+}
+
+func appendDictRule(t *testing.T, fileName string, rule string) {
+	file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	file.WriteString(fmt.Sprintf("%s\n", rule))
+}
+
+func assertReloadChangedDict(t *testing.T, mod *ModSwears, expected bool) {
+	actual := mod.reloadChangedDict()
+	if actual != expected {
+		t.Fatalf("Expected changed dictionary reload to return %v, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,211 @@
+package modswears
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var emojiRegex = regexp.MustCompile(`^:[a-z0-9_+'-]+:`)
+
+// Slack escapes these characters in message text.
+var slackEntities = []string{"&gt;", "&lt;", "&amp;"}
+
+// Word of a message with its byte offsets in the message.
+type wordSpan struct {
+	text  string
+	start int
+	end   int
+}
+
+// Splits Slack messages into words. Letters, digits and separator, mask
+// and lookalike characters make up words, even if the normalizer is set
+// to leave them, anything else separates words, so punctuation around
+// words is left out. Separator and mask characters are trimmed from ends
+// of words, so formatting marks are left out as well. Mentions, channel
+// links and emoji are never words, code, quotes and links are skipped as
+// set in config.
+type messageTokenizer struct {
+	wordRunes      map[rune]bool
+	lookalikes     map[rune]bool
+	skipInlineCode bool
+	skipCodeBlocks bool
+	skipQuotes     bool
+	skipLinks      bool
+}
+
+func newMessageTokenizer(config *ModSwearsConfig) *messageTokenizer {
+	tokenizer := &messageTokenizer{
+		wordRunes:      map[rune]bool{},
+		lookalikes:     map[rune]bool{},
+		skipInlineCode: config.SkipInlineCode,
+		skipCodeBlocks: config.SkipCodeBlocks,
+		skipQuotes:     config.SkipQuotes,
+		skipLinks:      config.SkipLinks,
+	}
+	for _, r := range config.SeparatorChars + config.MaskChars {
+		tokenizer.wordRunes[r] = true
+	}
+	for from := range config.LookalikeChars {
+		for _, r := range from {
+			tokenizer.wordRunes[r] = true
+			tokenizer.lookalikes[r] = true
+		}
+	}
+	return tokenizer
+}
+
+func (t *messageTokenizer) tokenize(message string) []wordSpan {
+	spans := []wordSpan{}
+	start := -1
+	endWord := func(end int) {
+		if start >= 0 {
+			if span, ok := t.trimWord(message, start, end); ok {
+				spans = append(spans, span)
+			}
+			start = -1
+		}
+	}
+	for offset := 0; offset < len(message); {
+		if length := t.markupLength(message, offset); length > 0 {
+			endWord(offset)
+			offset += length
+			continue
+		}
+		r, width := utf8.DecodeRuneInString(message[offset:])
+		if t.isWordRune(r) {
+			if start < 0 {
+				start = offset
+			}
+		} else {
+			endWord(offset)
+		}
+		offset += width
+	}
+	endWord(len(message))
+	return spans
+}
+
+// Trims separator and mask characters from ends of the word. Returns false
+// if nothing is left.
+func (t *messageTokenizer) trimWord(message string, start int, end int) (wordSpan, bool) {
+	for start < end {
+		r, width := utf8.DecodeRuneInString(message[start:end])
+		if !t.isTrimmed(r) {
+			break
+		}
+		start += width
+	}
+	for start < end {
+		r, width := utf8.DecodeLastRuneInString(message[start:end])
+		if !t.isTrimmed(r) {
+			break
+		}
+		end -= width
+	}
+	return newWordSpan(message, start, end), start < end
+}
+
+func (t *messageTokenizer) isTrimmed(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !t.lookalikes[r]
+}
+
+func (t *messageTokenizer) isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || t.wordRunes[r]
+}
+
+// Returns length of markup to skip at the offset, zero if there is none.
+func (t *messageTokenizer) markupLength(message string, offset int) int {
+	rest := message[offset:]
+	switch {
+	case strings.HasPrefix(rest, "```"):
+		if t.skipCodeBlocks {
+			return closedLength(rest, "```")
+		}
+	case strings.HasPrefix(rest, "`"):
+		if t.skipInlineCode {
+			return closedLength(rest, "`")
+		}
+	case strings.HasPrefix(rest, "<"):
+		return t.angleLength(rest)
+	case strings.HasPrefix(rest, ":"):
+		return len(emojiRegex.FindString(rest))
+	}
+	if t.skipQuotes && (offset == 0 || message[offset-1] == '\n') {
+		if length := quoteLength(rest); length > 0 {
+			return length
+		}
+	}
+	for _, entity := range slackEntities {
+		if strings.HasPrefix(rest, entity) {
+			return len(entity)
+		}
+	}
+	return 0
+}
+
+// Mentions, channel links and special commands in angle brackets are
+// skipped whole. Links are skipped whole or up to their label.
+func (t *messageTokenizer) angleLength(rest string) int {
+	end := strings.Index(rest, ">")
+	if end < 0 {
+		return 0
+	}
+	content := rest[1:end]
+	if t.skipLinks || strings.HasPrefix(content, "@") ||
+		strings.HasPrefix(content, "#") || strings.HasPrefix(content, "!") {
+		return end + 1
+	}
+	if label := strings.Index(content, "|"); label >= 0 {
+		return label + 2
+	}
+	return 1
+}
+
+// Returns length of text from the delimiter at the start of rest to the
+// closing delimiter, zero if it is not closed.
+func closedLength(rest string, delimiter string) int {
+	end := strings.Index(rest[len(delimiter):], delimiter)
+	if end < 0 {
+		return 0
+	}
+	return end + 2*len(delimiter)
+}
+
+// Returns length of quote starting at the start of a line. A line quote
+// lasts to the end of the line, a block quote to the end of the message.
+func quoteLength(rest string) int {
+	for _, blockQuote := range []string{"&gt;&gt;&gt;", ">>>"} {
+		if strings.HasPrefix(rest, blockQuote) {
+			return len(rest)
+		}
+	}
+	for _, quote := range []string{"&gt;", ">"} {
+		if strings.HasPrefix(rest, quote) {
+			if end := strings.Index(rest, "\n"); end >= 0 {
+				return end
+			}
+			return len(rest)
+		}
+	}
+	return 0
+}
+
+// Returns index of the span containing the offset or -1.
+func findSpan(spans []wordSpan, offset int) int {
+	for i, span := range spans {
+		if offset >= span.start && offset < span.end {
+			return i
+		}
+	}
+	return -1
+}
+
+func newWordSpan(message string, start int, end int) wordSpan {
+	return wordSpan{
+		text:  message[start:end],
+		start: start,
+		end:   end,
+	}
+}
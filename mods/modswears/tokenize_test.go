@@ -0,0 +1,65 @@
+package modswears
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tokenizer := newMessageTokenizer(NewModSwearsConfig())
+	assertTokenize(t, tokenizer, "ab, (cd)!", []wordSpan{
+		wordSpan{text: "ab", start: 0, end: 2},
+		wordSpan{text: "cd", start: 5, end: 7},
+	})
+	assertTokenize(t, tokenizer, "*ąb* _c-d_ $ab", []wordSpan{
+		wordSpan{text: "ąb", start: 1, end: 4},
+		wordSpan{text: "c-d", start: 7, end: 10},
+		wordSpan{text: "$ab", start: 12, end: 15},
+	})
+	assertTokenizedWords(t, tokenizer, "ab -- ... cd", "ab", "cd")
+	assertTokenizedWords(t, tokenizer, "<@U1> ab <#C1|general> :cd_ef: <!here>", "ab")
+	assertTokenizedWords(t, tokenizer, "ab `cd` ```ef\ngh``` ij", "ab", "ij")
+	assertTokenizedWords(t, tokenizer, "ab `cd", "ab", "cd")
+	assertTokenizedWords(t, tokenizer, "&gt; ab\ncd &gt; ef\n> gh", "cd", "ef")
+	assertTokenizedWords(t, tokenizer, "ab\n&gt;&gt;&gt; cd\nef", "ab")
+	assertTokenizedWords(t, tokenizer, "ab <http://cd.ef|gh ij> kl", "ab", "kl")
+}
+
+func TestTokenizeSkipsDisabled(t *testing.T) {
+	config := NewModSwearsConfig()
+	config.SkipInlineCode = false
+	config.SkipCodeBlocks = false
+	config.SkipQuotes = false
+	config.SkipLinks = false
+	tokenizer := newMessageTokenizer(config)
+	assertTokenizedWords(t, tokenizer, "<@U1> `ab` ```cd```", "ab", "cd")
+	assertTokenizedWords(t, tokenizer, "&gt; ab", "ab")
+	assertTokenizedWords(t, tokenizer, "<http://cd.ef|gh ij> <http://kl.mn>", "gh", "ij", "http", "kl.mn")
+}
+
+func TestFindSwearsInMarkup(t *testing.T) {
+	tmpFileName := createTmpDict(t)
+	defer os.Remove(tmpFileName)
+
+	mod := createSwears(t, tmpFileName)
+	expected := []string{"abcd", "abba", "a"}
+	assertFindSwears(t, mod, "abcd! (abba) *a* `abcd` <http://abcd.com> :abba:", expected)
+}
+
+func assertTokenize(t *testing.T, tokenizer *messageTokenizer, message string, expected []wordSpan) {
+	actual := tokenizer.tokenize(message)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected spans %#v of '%s', got %#v", expected, message, actual)
+	}
+}
+
+func assertTokenizedWords(t *testing.T, tokenizer *messageTokenizer, message string, expected ...string) {
+	actual := []string{}
+	for _, span := range tokenizer.tokenize(message) {
+		actual = append(actual, span.text)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected words %#v of '%s', got %#v", expected, message, actual)
+	}
+}
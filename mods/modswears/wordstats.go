@@ -0,0 +1,82 @@
+package modswears
+
+import (
+	"sort"
+	"strings"
+)
+
+// Swears written the same way, regardless of case. Swears of events
+// without text are grouped by their rule.
+type WordStats struct {
+	Word       string
+	SwearCount int
+	Score      int
+}
+
+type ByWordSwearCount []*WordStats
+
+func (a ByWordSwearCount) Len() int {
+	return len(a)
+}
+
+func (a ByWordSwearCount) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
+}
+
+// Swears with equal counts are ordered by score, then alphabetically.
+func (a ByWordSwearCount) Less(i, j int) bool {
+	if a[i].SwearCount != a[j].SwearCount {
+		return a[i].SwearCount > a[j].SwearCount
+	}
+	if a[i].Score != a[j].Score {
+		return a[i].Score > a[j].Score
+	}
+	return a[i].Word < a[j].Word
+}
+
+// GetTopSwears sums events matching the query per swear and returns at
+// most limit most used swears. Events without text and rule, imported
+// from monthly stats, are left out.
+func (mod *ModSwears) GetTopSwears(query StatsQuery, limit int) ([]*WordStats, int) {
+	events, err := mod.stats.Query(query)
+	if err != Success {
+		return nil, err
+	}
+	top := wordStatsOf(events)
+	sort.Stable(ByWordSwearCount(top))
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	return top, Success
+}
+
+// Sums events per swear in order of first use. Swears which have all been
+// reversed are left out.
+func wordStatsOf(events []SwearEvent) []*WordStats {
+	wordToStats := make(map[string]*WordStats)
+	wordStats := []*WordStats{}
+	for _, event := range events {
+		key := strings.ToLower(event.Text)
+		if key == "" {
+			key = event.Rule
+		}
+		if key == "" {
+			continue
+		}
+		word := wordToStats[key]
+		if word == nil {
+			word = &WordStats{Word: key}
+			wordToStats[key] = word
+			wordStats = append(wordStats, word)
+		}
+		word.SwearCount += event.Count
+		word.Score += event.Score
+	}
+	counted := wordStats[:0]
+	for _, word := range wordStats {
+		if word.SwearCount != 0 {
+			counted = append(counted, word)
+		}
+	}
+	return counted
+}
@@ -0,0 +1,109 @@
+package modswears
+
+import (
+	"../../utils"
+	"reflect"
+	"testing"
+)
+
+func TestTopSwears(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+
+	mod := createStats(t, tmpFilePath)
+	assertAddSwearCount(t, mod, 1, 2016, "user1", 10)
+	assertAddSwears(t, mod, 1, 2016, "user1", "b", "a", "a")
+	assertAddSwears(t, mod, 1, 2016, "user2", "c", "b")
+	assertAddSwears(t, mod, 2, 2016, "user2", "c", "c")
+
+	assertTopSwears(t, mod, StatsQuery{}, 10, []*WordStats{
+		&WordStats{Word: "c", SwearCount: 3, Score: 6},
+		&WordStats{Word: "a", SwearCount: 2, Score: 4},
+		&WordStats{Word: "b", SwearCount: 2, Score: 4},
+	})
+	assertTopSwears(t, mod, monthQuery(1, 2016), 2, []*WordStats{
+		&WordStats{Word: "a", SwearCount: 2, Score: 4},
+		&WordStats{Word: "b", SwearCount: 2, Score: 4},
+	})
+	assertTopSwears(t, mod, StatsQuery{UserId: "user1"}, 10, []*WordStats{
+		&WordStats{Word: "a", SwearCount: 2, Score: 4},
+		&WordStats{Word: "b", SwearCount: 1, Score: 2},
+	})
+}
+
+func TestTopSwearsByText(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+
+	mod := createStats(t, tmpFilePath)
+	now := utils.NewLocalDate(2016, 1, 2)
+	mod.AddSwears(now, "user1", "channel1", "", []SwearMatch{
+		SwearMatch{Text: "Kurwa", Rule: "kurw*"},
+		SwearMatch{Text: "kurwy", Rule: "kurw*"},
+		SwearMatch{Text: "kurwa", Rule: "kurw*"},
+	})
+	mod.stats.Add([]SwearEvent{SwearEvent{UserId: "user1", Time: now, Rule: "dup*", Count: 1, Score: 2}})
+
+	assertTopSwears(t, mod, StatsQuery{}, 10, []*WordStats{
+		&WordStats{Word: "kurwa", SwearCount: 2, Score: 4},
+		&WordStats{Word: "dup*", SwearCount: 1, Score: 2},
+		&WordStats{Word: "kurwy", SwearCount: 1, Score: 2},
+	})
+}
+
+func TestGetTopSwearsOfMonth(t *testing.T) {
+	tmpFilePath := createTmpStatsPath(t)
+	defer removeStatsFiles(tmpFilePath)
+
+	mod := createStats(t, tmpFilePath)
+	mod.config.TopSwearLineFormat = "{index}. {swear}: {count}"
+	assertAddSwears(t, mod, 3, 2016, "user1", "a")
+	assertAddSwears(t, mod, 4, 2016, "user1", "b")
+	expected := "*Top Swears* - March 2016\n1. a: 1\n"
+	if actual := mod.getTopSwears("march 2016", ""); actual != expected {
+		t.Fatalf("Expected top swears '%s', got '%s'", expected, actual)
+	}
+	if actual := mod.getTopSwears("3 2016", ""); actual != expected {
+		t.Fatalf("Expected top swears '%s', got '%s'", expected, actual)
+	}
+	if actual := mod.getTopSwears("smarch 2016", ""); actual != mod.config.OnInvalidPeriodErr {
+		t.Fatalf("Expected invalid period error, got '%s'", actual)
+	}
+}
+
+func TestFormatTopSwears(t *testing.T) {
+	config := NewModSwearsConfig()
+	config.TopSwearLineFormat = "{index}. {swear}: {count}/{score}"
+	wordStats := []*WordStats{
+		&WordStats{Word: "a", SwearCount: 2, Score: 4},
+	}
+	assertFormatTopSwears(t, config, 0, 0, "", wordStats, "*Top Swears*\n1. a: 2/4\n")
+	assertFormatTopSwears(t, config, 3, 2016, "", wordStats, "*Top Swears* - March 2016\n1. a: 2/4\n")
+	assertFormatTopSwears(t, config, 0, 0, "john", wordStats, "*Favourite Swears of john*\n1. a: 2/4\n")
+	assertFormatTopSwears(t, config, 3, 2016, "john", wordStats, "*Favourite Swears of john* - March 2016\n1. a: 2/4\n")
+}
+
+func assertTopSwears(t *testing.T, mod *ModSwears, query StatsQuery, limit int, expected []*WordStats) {
+	actual, err := mod.GetTopSwears(query, limit)
+	if err != Success {
+		t.Fatalf("Expected no error when getting top swears but got %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected top swears %v, got %v", expected, actual)
+	}
+}
+
+func assertFormatTopSwears(
+	t *testing.T,
+	config *ModSwearsConfig,
+	month int,
+	year int,
+	user string,
+	wordStats []*WordStats,
+	expected string) {
+
+	actual := formatTopSwears(config, month, year, user, wordStats)
+	if actual != expected {
+		t.Fatalf("Expected top swears '%s', got '%s'", expected, actual)
+	}
+}
@@ -10,12 +10,14 @@ type State interface {
 	Settings() settings.Settings
 	SaveSettings() int
 	SlackClient() *slack.Client
+	Users() UserDirectory
 	AsyncResponse(response Response)
 }
 
 type state struct {
 	settings         settings.SettingsManager
 	slackClient      *slack.Client
+	users            *userDirectory
 	asyncResponse    chan Response
 	settingsFilePath string
 }
@@ -32,6 +34,10 @@ func (s *state) SlackClient() *slack.Client {
 	return s.slackClient
 }
 
+func (s *state) Users() UserDirectory {
+	return s.users
+}
+
 func (s *state) AsyncResponse(response Response) {
 	s.asyncResponse <- response
 }
@@ -40,16 +46,21 @@ func NewState(slackClient *slack.Client, asyncResponse chan Response) *state {
 	return &state{
 		settings:      settings.NewSettings(),
 		slackClient:   slackClient,
+		users:         newUserDirectory(),
 		asyncResponse: asyncResponse,
 	}
 }
 
-func (s *state) Init(settingsFilePath string) bool {
+func (s *state) Init(settingsFilePath string, usersFilePath string) bool {
 	err := s.settings.Load(settingsFilePath)
 	if err != Success {
 		log.Printf("Mods: cannot load state settings from file '%s'\n", settingsFilePath)
 		return false
 	}
 	s.settingsFilePath = settingsFilePath
+	err = s.users.Load(usersFilePath)
+	if err != Success {
+		log.Printf("Mods: cannot load users from file '%s', starting with no users\n", usersFilePath)
+	}
 	return true
 }
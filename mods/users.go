@@ -0,0 +1,101 @@
+package mods
+
+import (
+	"../utils"
+	"encoding/json"
+	"github.com/nlopes/slack"
+	"log"
+	"sync"
+)
+
+const (
+	UsersFileReadErr = 71
+	UsersSaveErr     = 72
+)
+
+// UserDirectory keeps users of the team, so mods can show names of users
+// without asking Slack every time, or when Slack cannot be reached. It is
+// filled when the bot connects, kept up to date with user events and
+// saved to disk on every change.
+type UserDirectory interface {
+	GetUser(userId string) (slack.User, bool)
+	Len() int
+	SetUsers(users []slack.User) int
+	SetUser(user slack.User) int
+}
+
+type userDirectory struct {
+	mutex    sync.RWMutex
+	fileName string
+	Users    map[string]slack.User
+}
+
+func newUserDirectory() *userDirectory {
+	return &userDirectory{
+		Users: map[string]slack.User{},
+	}
+}
+
+// Load reads users from the file. If the file cannot be read the
+// directory is left empty and still saved to the file on the next change,
+// so the file is rebuilt when users are fetched again.
+func (d *userDirectory) Load(fileName string) int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.fileName = fileName
+	err := utils.JsonFromFileCreate(fileName, d)
+	if err != nil {
+		log.Printf("Mods: Cannot read users from file '%s'\n", fileName)
+		d.Users = map[string]slack.User{}
+		return UsersFileReadErr
+	}
+	return Success
+}
+
+func (d *userDirectory) GetUser(userId string) (slack.User, bool) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	user, ok := d.Users[userId]
+	return user, ok
+}
+
+func (d *userDirectory) Len() int {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return len(d.Users)
+}
+
+// SetUsers replaces all users with the given ones.
+func (d *userDirectory) SetUsers(users []slack.User) int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.Users = make(map[string]slack.User, len(users))
+	for _, user := range users {
+		d.Users[user.ID] = user
+	}
+	return d.save()
+}
+
+// SetUser adds the user or replaces the user of the same id.
+func (d *userDirectory) SetUser(user slack.User) int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.Users[user.ID] = user
+	return d.save()
+}
+
+// Must be called with mutex held.
+func (d *userDirectory) save() int {
+	if d.fileName == "" {
+		return Success
+	}
+	bytes, err := json.MarshalIndent(d, "", "    ")
+	if err == nil {
+		err = utils.WriteFileAtomic(d.fileName, bytes)
+	}
+	if err != nil {
+		log.Printf("Mods: Cannot write users to file '%s'\n", d.fileName)
+		return UsersSaveErr
+	}
+	return Success
+}
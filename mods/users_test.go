@@ -0,0 +1,73 @@
+package mods
+
+import (
+	"../utils"
+	"github.com/nlopes/slack"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestUserDirectory(t *testing.T) {
+	fileName := utils.CreateTmpFileName("Users")
+	if fileName == "" {
+		t.Fatal("Cannot create temp users file path")
+	}
+	defer os.Remove(fileName)
+
+	users := assertLoadUsers(t, fileName)
+	assertUserName(t, users, "U1", "")
+	users.SetUsers([]slack.User{
+		slack.User{ID: "U1", Name: "john"},
+		slack.User{ID: "U2", Name: "jane"},
+	})
+	users.SetUser(slack.User{ID: "U2", Name: "jane2"})
+	users.SetUser(slack.User{ID: "U3", Name: "jim"})
+
+	users = assertLoadUsers(t, fileName)
+	if users.Len() != 3 {
+		t.Fatalf("Expected 3 users, got %d", users.Len())
+	}
+	assertUserName(t, users, "U1", "john")
+	assertUserName(t, users, "U2", "jane2")
+	users.SetUsers([]slack.User{slack.User{ID: "U3", Name: "jim"}})
+	assertUserName(t, users, "U1", "")
+}
+
+func TestUserDirectoryCorruptFile(t *testing.T) {
+	fileName := utils.CreateTmpFileName("Users")
+	if fileName == "" {
+		t.Fatal("Cannot create temp users file path")
+	}
+	defer os.Remove(fileName)
+	if err := ioutil.WriteFile(fileName, []byte(`{"Users": {"U1": {"id": "U1", "na`), 0666); err != nil {
+		t.Fatalf("Cannot write users file: %v", err)
+	}
+
+	users := newUserDirectory()
+	if err := users.Load(fileName); err != UsersFileReadErr {
+		t.Fatalf("Expected error %d when loading corrupt users, got %v", UsersFileReadErr, err)
+	}
+	if users.Len() != 0 {
+		t.Fatalf("Expected no users after loading corrupt file, got %d", users.Len())
+	}
+	users.SetUser(slack.User{ID: "U1", Name: "john"})
+
+	users = assertLoadUsers(t, fileName)
+	assertUserName(t, users, "U1", "john")
+}
+
+func assertLoadUsers(t *testing.T, fileName string) *userDirectory {
+	users := newUserDirectory()
+	if err := users.Load(fileName); err != Success {
+		t.Fatalf("Expected to load users without errors, got %v", err)
+	}
+	return users
+}
+
+func assertUserName(t *testing.T, users UserDirectory, userId string, expected string) {
+	user, ok := users.GetUser(userId)
+	if ok != (expected != "") || user.Name != expected {
+		t.Fatalf("Expected user '%s' of id %s, got '%s'", expected, userId, user.Name)
+	}
+}
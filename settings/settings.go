@@ -2,7 +2,10 @@ package settings
 
 import (
 	"../utils"
+	"encoding/json"
 	"log"
+	"sort"
+	"sync"
 )
 
 const (
@@ -16,6 +19,7 @@ type Settings interface {
 	GetUserSetting(userId string, key string) (string, bool)
 	GetChanSetting(channelId string, key string) (string, bool)
 	GetSetting(key string) (string, bool)
+	GetChansWithSetting(key string) []string
 	SetUserChanSetting(userId string, channelId string, key string, value string)
 	SetUserSetting(userId string, key string, value string)
 	SetChanSetting(channelId string, key string, value string)
@@ -32,7 +36,10 @@ type SettingsManager interface {
 	Save(fileName string) int
 }
 
+// AllSettings may be used by several goroutines at once, mods reading
+// settings in background share them with mods processing messages.
 type AllSettings struct {
+	mutex        sync.RWMutex
 	UserSettings map[string]*UserSettings
 	ChanSettings map[string]*ChanSettings
 	Settings     map[string]string
@@ -62,6 +69,8 @@ func (settings *AllSettings) GetUserChanSetting(
 	channelId string,
 	key string) (string, bool) {
 
+	settings.mutex.RLock()
+	defer settings.mutex.RUnlock()
 	userSettings, userOk := settings.UserSettings[userId]
 	if userOk {
 		chanSettings, chanOk := userSettings.ChanSettings[channelId]
@@ -77,6 +86,8 @@ func (settings *AllSettings) GetUserSetting(
 	userId string,
 	key string) (string, bool) {
 
+	settings.mutex.RLock()
+	defer settings.mutex.RUnlock()
 	userSettings, userOk := settings.UserSettings[userId]
 	if userOk {
 		value, ok := userSettings.Settings[key]
@@ -89,6 +100,8 @@ func (settings *AllSettings) GetChanSetting(
 	channelId string,
 	key string) (string, bool) {
 
+	settings.mutex.RLock()
+	defer settings.mutex.RUnlock()
 	chanSettings, chanOk := settings.ChanSettings[channelId]
 	if chanOk {
 		value, ok := chanSettings.Settings[key]
@@ -98,16 +111,34 @@ func (settings *AllSettings) GetChanSetting(
 }
 
 func (settings *AllSettings) GetSetting(key string) (string, bool) {
+	settings.mutex.RLock()
+	defer settings.mutex.RUnlock()
 	value, ok := settings.Settings[key]
 	return value, ok
 }
 
+// GetChansWithSetting returns sorted ids of channels having the setting.
+func (settings *AllSettings) GetChansWithSetting(key string) []string {
+	settings.mutex.RLock()
+	defer settings.mutex.RUnlock()
+	channelIds := []string{}
+	for channelId, chanSettings := range settings.ChanSettings {
+		if _, ok := chanSettings.Settings[key]; ok {
+			channelIds = append(channelIds, channelId)
+		}
+	}
+	sort.Strings(channelIds)
+	return channelIds
+}
+
 func (settings *AllSettings) SetUserChanSetting(
 	userId string,
 	channelId string,
 	key string,
 	value string) {
 
+	settings.mutex.Lock()
+	defer settings.mutex.Unlock()
 	userSettings, userOk := settings.UserSettings[userId]
 	if !userOk {
 		userSettings = createUserSettings(userId)
@@ -126,6 +157,8 @@ func (settings *AllSettings) SetUserSetting(
 	key string,
 	value string) {
 
+	settings.mutex.Lock()
+	defer settings.mutex.Unlock()
 	userSettings, userOk := settings.UserSettings[userId]
 	if !userOk {
 		userSettings = createUserSettings(userId)
@@ -139,6 +172,8 @@ func (settings *AllSettings) SetChanSetting(
 	key string,
 	value string) {
 
+	settings.mutex.Lock()
+	defer settings.mutex.Unlock()
 	chanSettings, chanOk := settings.ChanSettings[channelId]
 	if !chanOk {
 		chanSettings = createChanSettings(channelId)
@@ -148,6 +183,8 @@ func (settings *AllSettings) SetChanSetting(
 }
 
 func (settings *AllSettings) SetSetting(key string, value string) {
+	settings.mutex.Lock()
+	defer settings.mutex.Unlock()
 	settings.Settings[key] = value
 }
 
@@ -156,6 +193,8 @@ func (settings *AllSettings) RemoveUserChanSetting(
 	chanelId string,
 	key string) bool {
 
+	settings.mutex.Lock()
+	defer settings.mutex.Unlock()
 	userSettings, userOk := settings.UserSettings[userId]
 	if userOk {
 		chanSettings, chanOk := userSettings.ChanSettings[chanelId]
@@ -169,6 +208,8 @@ func (settings *AllSettings) RemoveUserChanSetting(
 }
 
 func (settings *AllSettings) RemoveUserSetting(userId string, key string) bool {
+	settings.mutex.Lock()
+	defer settings.mutex.Unlock()
 	userSettings, userOk := settings.UserSettings[userId]
 	if userOk {
 		_, ok := userSettings.Settings[key]
@@ -179,6 +220,8 @@ func (settings *AllSettings) RemoveUserSetting(userId string, key string) bool {
 }
 
 func (settings *AllSettings) RemoveChanSetting(channelId string, key string) bool {
+	settings.mutex.Lock()
+	defer settings.mutex.Unlock()
 	chanSettings, chanOk := settings.ChanSettings[channelId]
 	if chanOk {
 		_, ok := chanSettings.Settings[key]
@@ -189,12 +232,16 @@ func (settings *AllSettings) RemoveChanSetting(channelId string, key string) boo
 }
 
 func (settings *AllSettings) RemoveSetting(key string) bool {
+	settings.mutex.Lock()
+	defer settings.mutex.Unlock()
 	_, ok := settings.Settings[key]
 	delete(settings.Settings, key)
 	return ok
 }
 
 func (settings *AllSettings) Load(fileName string) int {
+	settings.mutex.Lock()
+	defer settings.mutex.Unlock()
 	err := utils.JsonFromFileCreate(fileName, settings)
 	if err != nil {
 		log.Printf("Settings: Cannot read settings from file '%s'\n", fileName)
@@ -203,8 +250,16 @@ func (settings *AllSettings) Load(fileName string) int {
 	return Success
 }
 
+// Save writes settings to a temporary file renamed over the settings file,
+// so the file is never left partially written. Saves are serialized by the
+// write lock, an older snapshot cannot overwrite a newer one.
 func (settings *AllSettings) Save(fileName string) int {
-	err := utils.JsonToFile(fileName, settings)
+	settings.mutex.Lock()
+	defer settings.mutex.Unlock()
+	bytes, err := json.MarshalIndent(settings, "", "    ")
+	if err == nil {
+		err = utils.WriteFileAtomic(fileName, bytes)
+	}
 	if err != nil {
 		log.Printf("Settings: Cannot write settings to file '%s'\n", fileName)
 		return SettingsSaveErr
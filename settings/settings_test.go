@@ -4,6 +4,8 @@ import (
 	"../utils"
 	"os"
 	"reflect"
+	"strconv"
+	"sync"
 	"testing"
 )
 
@@ -63,6 +65,32 @@ func TestWriteReadSettings(t *testing.T) {
 	assertLoadSettings(t, fileName, settings)
 }
 
+func TestConcurrentSaves(t *testing.T) {
+	fileName := createTmpSettingsPath(t)
+	defer os.Remove(fileName)
+
+	settings := NewSettings()
+	errs := make(chan int, 20)
+	var wait sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			settings.SetSetting("key"+strconv.Itoa(i), "val")
+			errs <- settings.Save(fileName)
+		}(i)
+	}
+	wait.Wait()
+	close(errs)
+	for err := range errs {
+		if err != Success {
+			t.Fatalf("Expected no errors when writing settings concurrently, got %v", err)
+		}
+	}
+	assertSaveSettings(t, fileName, settings)
+	assertLoadSettings(t, fileName, settings)
+}
+
 func TestSettingSettings(t *testing.T) {
 	settings := NewSettings()
 	settings.SetSetting("k1", "v1")
@@ -121,6 +149,19 @@ func TestSettingChanSettings(t *testing.T) {
 	assertNotRemoveChanSetting(t, settings, "c3", "k1")
 }
 
+func TestChansWithSetting(t *testing.T) {
+	settings := NewSettings()
+	assertChansWithSetting(t, settings, "k1", []string{})
+	settings.SetChanSetting("c2", "k1", "c2v1")
+	settings.SetChanSetting("c1", "k1", "c1v1")
+	settings.SetChanSetting("c3", "k2", "c3v2")
+	settings.SetUserChanSetting("u1", "c4", "k1", "u1c4v1")
+
+	assertChansWithSetting(t, settings, "k1", []string{"c1", "c2"})
+	settings.RemoveChanSetting("c2", "k1")
+	assertChansWithSetting(t, settings, "k1", []string{"c1"})
+}
+
 func TestSettingUserChanSettings(t *testing.T) {
 	settings := NewSettings()
 	settings.SetUserChanSetting("u1", "c1", "k1", "u1c1v1")
@@ -400,3 +441,10 @@ func assertNotRemoveUserChanSetting(
 			key)
 	}
 }
+
+func assertChansWithSetting(t *testing.T, settings *AllSettings, key string, expected []string) {
+	actual := settings.GetChansWithSetting(key)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected channels %v with setting '%s', got %v", expected, key, actual)
+	}
+}
@@ -1,9 +1,11 @@
 #!/bin/sh
 go test ./dictmatch
 go test ./bot
+go test ./mods
 go test ./mods/modswears
 go test ./mods/modchoice
 go test ./mods/modmention
 go test ./mods/modicm
 go test ./utils
-go test ./settings
\ No newline at end of file
+go test ./settings
+go test ./kvstore
\ No newline at end of file
@@ -36,3 +36,10 @@ func LastDayOfPrevMonth(date time.Time) time.Time {
 	year := date.Year()
 	return time.Date(year, month, 1, 0, 0, 0, 0, date.Location()).AddDate(0, 0, -1)
 }
+
+// Returns midnight of Monday of the week the date falls in.
+func FirstDayOfWeek(date time.Time) time.Time {
+	daysSinceMonday := (int(date.Weekday()) + 6) % 7
+	year, month, day := date.Date()
+	return time.Date(year, month, day-daysSinceMonday, 0, 0, 0, 0, date.Location())
+}
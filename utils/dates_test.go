@@ -12,6 +12,14 @@ func TestLastDayOfPrevMonth(t *testing.T) {
 	assertLastDayOfPrevMonth(t, getDate(2016, 1, 3), getDate(2015, 12, 31))
 }
 
+func TestFirstDayOfWeek(t *testing.T) {
+	assertFirstDayOfWeek(t, getDate(2016, 6, 15), getDate(2016, 6, 13))
+	assertFirstDayOfWeek(t, getDate(2016, 6, 13), getDate(2016, 6, 13))
+	assertFirstDayOfWeek(t, getDate(2016, 6, 19), getDate(2016, 6, 13))
+	assertFirstDayOfWeek(t, getDate(2016, 3, 2), getDate(2016, 2, 29))
+	assertFirstDayOfWeek(t, time.Date(2016, 6, 15, 13, 30, 0, 0, time.Now().Location()), getDate(2016, 6, 13))
+}
+
 func getDate(year int, month int, day int) time.Time {
 	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Now().Location())
 }
@@ -22,3 +30,10 @@ func assertLastDayOfPrevMonth(t *testing.T, date time.Time, expected time.Time)
 		t.Errorf("Expected %v, got %v", expected, actual)
 	}
 }
+
+func assertFirstDayOfWeek(t *testing.T, date time.Time, expected time.Time) {
+	actual := FirstDayOfWeek(date)
+	if actual != expected {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 )
 
 func CreateTmpFileName(filePrefix string) string {
@@ -58,3 +59,29 @@ func JsonToFile(fileName string, in interface{}) error {
 	}
 	return nil
 }
+
+func WriteFileAtomic(fileName string, data []byte) error {
+	tmpFile, err := ioutil.TempFile(filepath.Dir(fileName), filepath.Base(fileName))
+	if err != nil {
+		log.Printf("Cannot create tmp file for '%s': %v\n", fileName, err)
+		return err
+	}
+	tmpFileName := tmpFile.Name()
+	_, err = tmpFile.Write(data)
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	closeErr := tmpFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpFileName, fileName)
+	}
+	if err != nil {
+		log.Printf("Cannot write file '%s': %v\n", fileName, err)
+		os.Remove(tmpFileName)
+		return err
+	}
+	return nil
+}